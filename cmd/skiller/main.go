@@ -2,13 +2,24 @@ package main
 
 import (
 	"log"
+	"os"
 
+	"skiller/internal/cli"
+	"skiller/internal/registrysync"
 	"skiller/internal/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
+	args := os.Args[1:]
+	if len(args) > 1 || (len(args) == 1 && args[0] != cli.OfflineFlag) {
+		os.Exit(cli.Run(args, os.Stdout, os.Stderr))
+	}
+	if len(args) == 1 {
+		registrysync.SetOffline(true)
+	}
+
 	model, err := ui.NewModel()
 	if err != nil {
 		log.Fatalf("failed to initialize skiller: %v", err)
@@ -0,0 +1,182 @@
+package adapter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"skiller/internal/scan"
+)
+
+type Format string
+
+const (
+	FormatSkill      Format = "skill"
+	FormatCursorRule Format = "cursor-rule"
+	FormatMarkdown   Format = "markdown"
+	FormatAgentsMD   Format = "agents-md"
+)
+
+var Formats = []Format{FormatSkill, FormatCursorRule, FormatMarkdown, FormatAgentsMD}
+
+const AgentsFileName = "AGENTS.md"
+
+func ParseFormat(value string) (Format, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(value))
+	if trimmed == "" {
+		return FormatSkill, nil
+	}
+	for _, format := range Formats {
+		if string(format) == trimmed {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown harness format %q (use skill, cursor-rule, markdown or agents-md)", value)
+}
+
+func (f Format) Shared() bool {
+	return f == FormatAgentsMD
+}
+
+func (f Format) FileName(name string) string {
+	switch f {
+	case FormatCursorRule:
+		return name + ".mdc"
+	case FormatAgentsMD:
+		return AgentsFileName
+	default:
+		return name + ".md"
+	}
+}
+
+func Render(format Format, skillDir, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(skillDir, scan.MarkerFileName))
+	if err != nil {
+		return nil, err
+	}
+	metadata, body, err := scan.ParseFrontmatter(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(skillDir, scan.MarkerFileName), err)
+	}
+	body = strings.TrimSpace(body)
+
+	var out bytes.Buffer
+	switch format {
+	case FormatCursorRule:
+		out.WriteString("---\n")
+		fmt.Fprintf(&out, "description: %s\n", yamlString(metadata.Description))
+		out.WriteString("globs:\n")
+		out.WriteString("alwaysApply: false\n")
+		out.WriteString("---\n")
+		writeSection(&out, name, body)
+	case FormatMarkdown:
+		if metadata.Description != "" {
+			fmt.Fprintf(&out, "---\ndescription: %s\n---\n", yamlString(metadata.Description))
+		}
+		writeSection(&out, name, body)
+	case FormatAgentsMD:
+		var section strings.Builder
+		fmt.Fprintf(&section, "## %s\n", name)
+		if metadata.Description != "" {
+			fmt.Fprintf(&section, "\n%s\n", metadata.Description)
+		}
+		if body != "" {
+			fmt.Fprintf(&section, "\n%s", demoteHeadings(body))
+		}
+		writeSection(&out, name, strings.TrimSpace(section.String()))
+	default:
+		return nil, fmt.Errorf("format %s is not rendered", format)
+	}
+	return out.Bytes(), nil
+}
+
+func writeSection(out *bytes.Buffer, name, content string) {
+	out.WriteString(scan.SectionBegin(name) + "\n")
+	if content != "" {
+		out.WriteString(content + "\n")
+	}
+	out.WriteString(scan.SectionEnd(name) + "\n")
+}
+
+func UpsertSection(doc []byte, name string, section []byte) []byte {
+	if start, end, ok := sectionBounds(doc, name); ok {
+		out := append([]byte(nil), doc[:start]...)
+		out = append(out, section...)
+		return append(out, doc[end:]...)
+	}
+
+	out := append([]byte(nil), doc...)
+	if len(out) > 0 {
+		if !bytes.HasSuffix(out, []byte("\n")) {
+			out = append(out, '\n')
+		}
+		out = append(out, '\n')
+	}
+	return append(out, section...)
+}
+
+func Section(doc []byte, name string) ([]byte, bool) {
+	start, end, ok := sectionBounds(doc, name)
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), doc[start:end]...), true
+}
+
+func RemoveSection(doc []byte, name string) ([]byte, bool) {
+	start, end, ok := sectionBounds(doc, name)
+	if !ok {
+		return doc, false
+	}
+
+	before := bytes.TrimRight(doc[:start], "\n")
+	after := bytes.TrimLeft(doc[end:], "\n")
+	out := append([]byte(nil), before...)
+	if len(before) > 0 && len(after) > 0 {
+		out = append(out, '\n', '\n')
+	} else if len(before) > 0 {
+		out = append(out, '\n')
+	}
+	return append(out, after...), true
+}
+
+func sectionBounds(doc []byte, name string) (int, int, bool) {
+	begin := bytes.Index(doc, []byte(scan.SectionBegin(name)))
+	if begin < 0 {
+		return 0, 0, false
+	}
+	endTag := []byte(scan.SectionEnd(name))
+	offset := bytes.Index(doc[begin:], endTag)
+	if offset < 0 {
+		return 0, 0, false
+	}
+	end := begin + offset + len(endTag)
+	if end < len(doc) && doc[end] == '\n' {
+		end++
+	}
+	return begin, end, true
+}
+
+func demoteHeadings(body string) string {
+	lines := strings.Split(body, "\n")
+	fenced := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+		}
+		if !fenced && strings.HasPrefix(line, "#") {
+			lines[i] = "#" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func yamlString(value string) string {
+	if value == "" {
+		return `""`
+	}
+	return strconv.Quote(value)
+}
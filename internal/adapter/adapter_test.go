@@ -0,0 +1,55 @@
+package adapter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"skiller/internal/scan"
+)
+
+func TestRenderAgentsSectionNestsHeadings(t *testing.T) {
+	dir := t.TempDir()
+	content := "---\ndescription: Review code\n---\n# Review\n\n```sh\n# not a heading\n```\n"
+	if err := os.WriteFile(filepath.Join(dir, scan.MarkerFileName), []byte(content), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+
+	section, err := Render(FormatAgentsMD, dir, "review")
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	want := scan.SectionBegin("review") + "\n## review\n\nReview code\n\n## Review\n\n```sh\n# not a heading\n```\n" + scan.SectionEnd("review") + "\n"
+	if string(section) != want {
+		t.Fatalf("unexpected section:\n%s", section)
+	}
+	if names := scan.SectionNames(section); len(names) != 1 || names[0] != "review" {
+		t.Fatalf("expected the section to be tagged, got %v", names)
+	}
+}
+
+func TestUpsertAndRemoveSection(t *testing.T) {
+	section := func(name, body string) []byte {
+		return []byte(scan.SectionBegin(name) + "\n" + body + "\n" + scan.SectionEnd(name) + "\n")
+	}
+
+	doc := []byte("# Rules\n")
+	doc = UpsertSection(doc, "a", section("a", "one"))
+	doc = UpsertSection(doc, "b", section("b", "two"))
+	doc = UpsertSection(doc, "a", section("a", "three"))
+	if strings.Contains(string(doc), "one") || strings.Index(string(doc), "three") > strings.Index(string(doc), "two") {
+		t.Fatalf("expected a to be replaced in place, got:\n%s", doc)
+	}
+
+	doc, removed := RemoveSection(doc, "a")
+	if !removed {
+		t.Fatalf("expected section a to be removed")
+	}
+	if string(doc) != "# Rules\n\n"+string(section("b", "two")) {
+		t.Fatalf("unexpected document:\n%q", doc)
+	}
+	if _, removed := RemoveSection(doc, "missing"); removed {
+		t.Fatalf("expected missing section to be left alone")
+	}
+}
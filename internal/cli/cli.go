@@ -0,0 +1,767 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"skiller/internal/config"
+	"skiller/internal/install"
+	"skiller/internal/lockfile"
+	"skiller/internal/provenance"
+	"skiller/internal/registrysync"
+	"skiller/internal/scan"
+)
+
+const syncTimeout = 4 * time.Minute
+
+var ErrUsage = errors.New("usage error")
+
+const OfflineFlag = "--offline"
+
+type command struct {
+	name    string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+type app struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	cfg        *config.Config
+	configPath string
+}
+
+func commands() []command {
+	return []command{
+		{name: "list", summary: "list [registries|skills|installed] [--format table|json|yaml]", run: runList},
+		{name: "install", summary: "install <registry>/<skill> --harness <path|all> [--harness <path>...] [--project] [--mode copy|symlink|hardlink] | install --frozen", run: runInstall},
+		{name: "uninstall", summary: "uninstall <skill> --harness <path>", run: runUninstall},
+		{name: "upgrade", summary: "upgrade [<skill>] --harness <path> | upgrade --all [--force]", run: runUpgrade},
+		{name: "diff", summary: "diff <skill>|<registry>/<skill> --harness <path> compares an installed skill with its registry version", run: runDiff},
+		{name: "lock", summary: "lock [--manifest skiller.toml] resolves the project manifest into skiller.lock", run: runLock},
+		{name: "sync", summary: "sync [registry] [--all] [--format table|json|yaml] remote registries", run: runSync},
+		{name: "registry", summary: "registry add <path|url> [--sync-interval 6h] | registry remove <id|source>", run: runRegistry},
+		{name: "harness", summary: "harness add <path> [--name <name>] [--kind <kind>] [--mode <mode>] [--conflict <policy>] | harness mode|enable|disable|remove <path|name>", run: runHarness},
+		{name: "trash", summary: "trash list | trash restore <id> [--force] | trash empty [--older-than 7d]", run: runTrash},
+	}
+}
+
+func Run(args []string, stdout, stderr io.Writer) int {
+	args, offline := stripOffline(args)
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stdout)
+		return 0
+	}
+
+	var selected *command
+	for _, cmd := range commands() {
+		if cmd.name == args[0] {
+			c := cmd
+			selected = &c
+			break
+		}
+	}
+	if selected == nil {
+		fmt.Fprintf(stderr, "skiller: unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+
+	cfg, configPath, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "skiller: failed to load config: %v\n", err)
+		return 1
+	}
+
+	if offline || cfg.Offline {
+		registrysync.SetOffline(true)
+	}
+
+	a := &app{
+		stdout:     stdout,
+		stderr:     stderr,
+		cfg:        cfg,
+		configPath: configPath,
+	}
+
+	if err := selected.run(a, args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(stderr, "skiller %s: %v\n", selected.name, err)
+		if errors.Is(err, ErrUsage) {
+			return 2
+		}
+		return 1
+	}
+
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: skiller [--offline] [command] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command skiller starts the interactive TUI. --offline skips all")
+	fmt.Fprintln(w, "network access and scans remote registries from their caches only.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+func runInstall(a *app, args []string) error {
+	fs := newFlagSet("install", a.stderr)
+	var harnessFlags stringList
+	fs.Var(&harnessFlags, "harness", "harness path to install into; repeat for several, or all for every harness")
+	conflictFlag := fs.String("conflict", "", "conflict action: skip, overwrite or rename (default: the harness setting, else skip)")
+	modeFlag := fs.String("mode", "", "install mode: copy, symlink or hardlink (default: the harness setting)")
+	project := fs.Bool("project", false, "install into the project harnesses of the current git repository")
+	frozen := fs.Bool("frozen", false, "install exactly the skills pinned in the project lockfile")
+	lockFlag := fs.String("lock", lockfile.LockFileName, "lockfile used with --frozen")
+	interactive := fs.Bool("interactive", false, "allow git to prompt for credentials")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *frozen {
+		if len(positional) > 0 || len(harnessFlags) > 0 || *project {
+			return usageErrorf("--frozen installs from the lockfile and takes no skill, --harness or --project")
+		}
+		return installFrozen(a, *lockFlag, *interactive)
+	}
+	if len(positional) != 1 {
+		return usageErrorf("expected exactly one <registry>/<skill> argument")
+	}
+
+	var actionOverride install.ConflictAction
+	if *conflictFlag != "" {
+		if actionOverride, err = parseConflictAction(*conflictFlag); err != nil {
+			return err
+		}
+	}
+
+	var harnesses []string
+	if *project {
+		if harnesses, err = a.projectInstallHarnesses(); err != nil {
+			return err
+		}
+	}
+	if len(harnessFlags) > 0 || !*project {
+		selected, err := a.resolveHarnesses(harnessFlags)
+		if err != nil {
+			return err
+		}
+		harnesses = config.MergeUnique(harnesses, selected)
+	}
+
+	var modeOverride config.InstallMode
+	if *modeFlag != "" {
+		if modeOverride, err = config.ParseInstallMode(*modeFlag); err != nil {
+			return usageErrorf("%v", err)
+		}
+	}
+
+	registry, skill, err := a.resolveRegistrySkill(positional[0])
+	if err != nil {
+		return err
+	}
+
+	if err := registrysync.FetchSkill(registry, skill.Path); err != nil {
+		return err
+	}
+	record, err := provenance.ForSkill(registry, skill)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, harness := range harnesses {
+		mode := modeOverride
+		if mode == "" {
+			mode = a.cfg.HarnessMode(harness)
+		}
+		action := actionOverride
+		if action == "" {
+			action = a.harnessConflict(harness)
+		}
+
+		result, err := install.InstallSkillWithOptions(skill.Path, harness, action, install.Options{Mode: mode, Provenance: &record, Format: a.cfg.HarnessFormat(harness)})
+		if err != nil {
+			if len(harnesses) == 1 {
+				return err
+			}
+			failed++
+			fmt.Fprintf(a.stderr, "failed to install %s into %s: %v\n", skill.Name, harness, err)
+			continue
+		}
+
+		switch {
+		case !result.Installed:
+			fmt.Fprintf(a.stdout, "skipped %s: already installed in %s\n", result.Name, harness)
+		case result.Trashed != nil:
+			fmt.Fprintf(a.stdout, "installed %s%s, previous version moved to trash as %s\n", result.Destination, modeSuffix(mode), result.Trashed.ID)
+		case result.Renamed:
+			fmt.Fprintf(a.stdout, "installed %s as %s%s\n", skill.Name, result.Destination, modeSuffix(mode))
+		default:
+			fmt.Fprintf(a.stdout, "installed %s%s\n", result.Destination, modeSuffix(mode))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d harnesses failed", failed, len(harnesses))
+	}
+	return nil
+}
+
+func modeSuffix(mode config.InstallMode) string {
+	if mode == config.InstallModeCopy {
+		return ""
+	}
+	return " (" + string(mode) + ")"
+}
+
+func runUninstall(a *app, args []string) error {
+	fs := newFlagSet("uninstall", a.stderr)
+	harnessFlag := fs.String("harness", "", "harness path to uninstall from")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("expected exactly one <skill> argument")
+	}
+
+	harness, err := a.resolveHarness(*harnessFlag)
+	if err != nil {
+		return err
+	}
+
+	entry, err := install.UninstallSkill(harness, positional[0], a.cfg.Registries)
+	if err != nil {
+		return err
+	}
+
+	if entry.ID == "" {
+		fmt.Fprintf(a.stdout, "uninstalled %s from %s\n", positional[0], harness)
+		return nil
+	}
+	restore := "skiller trash restore " + entry.ID
+	if _, err := os.Lstat(entry.OriginalPath); err == nil {
+		restore += " --force"
+	}
+	fmt.Fprintf(a.stdout, "uninstalled %s from %s (restore with: %s)\n", positional[0], harness, restore)
+	return nil
+}
+
+type syncDocument struct {
+	SchemaVersion int        `json:"schema_version"`
+	Registries    []syncView `json:"registries"`
+}
+
+type syncView struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Status         string   `json:"status"`
+	Summary        string   `json:"summary,omitempty"`
+	Commit         string   `json:"commit,omitempty"`
+	PreviousCommit string   `json:"previous_commit,omitempty"`
+	CommitsAhead   *int     `json:"commits_ahead,omitempty"`
+	Added          []string `json:"added,omitempty"`
+	Changed        []string `json:"changed,omitempty"`
+	Removed        []string `json:"removed,omitempty"`
+	Stat           string   `json:"stat,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+func runSync(a *app, args []string) error {
+	fs := newFlagSet("sync", a.stderr)
+	all := fs.Bool("all", false, "sync every remote registry")
+	interactive := fs.Bool("interactive", false, "allow git to prompt for credentials")
+	formats := addFormatFlags(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	format, err := formats.resolve()
+	if err != nil {
+		return err
+	}
+	if registrysync.Offline() {
+		return fmt.Errorf("cannot sync: %w", registrysync.ErrOffline)
+	}
+
+	var targets []config.Registry
+	switch {
+	case *all && len(positional) > 0:
+		return usageErrorf("--all cannot be combined with a registry argument")
+	case *all || len(positional) == 0:
+		for _, registry := range a.cfg.Registries {
+			if registry.IsRemote() {
+				targets = append(targets, registry)
+			}
+		}
+	default:
+		for _, identifier := range positional {
+			registry, err := a.resolveRegistry(identifier)
+			if err != nil {
+				return err
+			}
+			if !registry.IsRemote() {
+				return fmt.Errorf("registry %s is local", registry.DisplayName())
+			}
+			targets = append(targets, registry)
+		}
+	}
+
+	failed := 0
+	doc := syncDocument{SchemaVersion: SchemaVersion, Registries: []syncView{}}
+	for _, registry := range targets {
+		view := syncView{ID: registry.ID, Name: registry.DisplayName(), Status: "synced"}
+		result, err := registrysync.SyncRegistry(registry, *interactive, syncTimeout)
+		if err != nil {
+			failed++
+			view.Status, view.Error = "failed", err.Error()
+			doc.Registries = append(doc.Registries, view)
+			if format == formatTable {
+				fmt.Fprintf(a.stderr, "failed to sync %s: %v\n", registry.DisplayName(), err)
+			}
+			continue
+		}
+
+		view.Summary = result.Summary()
+		view.Commit, view.PreviousCommit = result.Commit, result.PreviousCommit
+		if result.CommitsAhead >= 0 {
+			ahead := result.CommitsAhead
+			view.CommitsAhead = &ahead
+		}
+		view.Added, view.Changed, view.Removed = result.Changes.Added, result.Changes.Changed, result.Changes.Removed
+		view.Stat = result.Changes.Stat
+		doc.Registries = append(doc.Registries, view)
+
+		if format == formatTable {
+			fmt.Fprintf(a.stdout, "%s: %s\n", registry.DisplayName(), result.Summary())
+			printSkillChanges(a.stdout, result.Changes)
+		}
+	}
+
+	if format != formatTable {
+		if err := writeDocument(a.stdout, format, doc, nil); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d registries failed to sync", failed, len(targets))
+	}
+	return nil
+}
+
+func printSkillChanges(w io.Writer, changes registrysync.SkillChanges) {
+	for _, group := range []struct {
+		label  string
+		skills []string
+	}{
+		{"added", changes.Added},
+		{"changed", changes.Changed},
+		{"removed", changes.Removed},
+	} {
+		for _, skill := range group.skills {
+			fmt.Fprintf(w, "  %-8s %s\n", group.label, skill)
+		}
+	}
+}
+
+func runRegistry(a *app, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("expected a registry subcommand: add or remove")
+	}
+
+	fs := newFlagSet("registry "+args[0], a.stderr)
+	syncInterval := fs.String("sync-interval", "", "with add: sync the registry again once its cache is this old, e.g. 6h or 7d")
+	positional, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("expected exactly one registry argument")
+	}
+	if *syncInterval != "" {
+		if args[0] != "add" {
+			return usageErrorf("--sync-interval is only valid with registry add")
+		}
+		if _, err := config.ParseAge(*syncInterval); err != nil {
+			return usageErrorf("invalid --sync-interval %q: %v", *syncInterval, err)
+		}
+	}
+
+	switch args[0] {
+	case "add":
+		before := len(a.cfg.Registries)
+		if err := a.cfg.AddRegistry(positional[0]); err != nil {
+			return err
+		}
+		if len(a.cfg.Registries) == before {
+			fmt.Fprintf(a.stdout, "registry already configured: %s\n", positional[0])
+			return nil
+		}
+		added := &a.cfg.Registries[len(a.cfg.Registries)-1]
+		added.SyncInterval = *syncInterval
+		if err := a.saveConfig(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "added registry %s (%s)\n", added.DisplayName(), added.ID)
+	case "remove":
+		registry, err := a.resolveRegistry(positional[0])
+		if err != nil {
+			return err
+		}
+		if registry.IsRemote() {
+			if err := registrysync.RemoveRegistryCache(registry); err != nil {
+				return err
+			}
+		}
+		a.cfg.RemoveRegistry(registry.ID)
+		if err := a.saveConfig(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "removed registry %s\n", registry.DisplayName())
+	default:
+		return usageErrorf("unknown registry subcommand %q", args[0])
+	}
+
+	return nil
+}
+
+func runHarness(a *app, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("expected a harness subcommand: add, mode, enable, disable or remove")
+	}
+
+	fs := newFlagSet("harness "+args[0], a.stderr)
+	nameFlag := fs.String("name", "", "name for the harness")
+	kindFlag := fs.String("kind", "", "harness definition the harness belongs to, or custom (default: guessed from the path)")
+	modeFlag := fs.String("mode", "", "install mode for the harness: copy, symlink or hardlink")
+	conflictFlag := fs.String("conflict", "", "default conflict policy for the harness: skip, overwrite or rename")
+	positional, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	wantArgs := 1
+	if args[0] == "mode" {
+		wantArgs = 2
+	}
+	if len(positional) != wantArgs {
+		if wantArgs == 2 {
+			return usageErrorf("expected a harness path and an install mode")
+		}
+		return usageErrorf("expected exactly one harness path")
+	}
+
+	path, err := config.ExpandPath(positional[0])
+	if err != nil {
+		return err
+	}
+	if args[0] != "add" {
+		if harness, ok := a.cfg.FindHarness(positional[0]); ok {
+			path = harness.Path
+		}
+	}
+
+	switch args[0] {
+	case "add":
+		if err := a.cfg.AddHarness(path); err != nil {
+			return err
+		}
+		err := a.cfg.UpdateHarness(path, func(harness *config.Harness) error {
+			if *nameFlag != "" {
+				harness.Name = *nameFlag
+			}
+			if *kindFlag != "" {
+				harness.Kind = config.HarnessKind(*kindFlag)
+			}
+			if *modeFlag != "" {
+				harness.InstallMode = config.InstallMode(*modeFlag)
+			}
+			if *conflictFlag != "" {
+				harness.Conflict = config.ConflictPolicy(*conflictFlag)
+			}
+			return nil
+		})
+		if err != nil {
+			return usageErrorf("%v", err)
+		}
+		if err := a.saveConfig(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "added harness %s%s\n", path, modeSuffix(a.cfg.HarnessMode(path)))
+	case "mode":
+		if err := a.cfg.SetHarnessMode(path, config.InstallMode(positional[1])); err != nil {
+			return usageErrorf("%v", err)
+		}
+		if err := a.saveConfig(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "harness %s installs with %s\n", path, a.cfg.HarnessMode(path))
+	case "enable", "disable":
+		enabled := args[0] == "enable"
+		if err := a.cfg.SetHarnessEnabled(path, enabled); err != nil {
+			return err
+		}
+		if err := a.saveConfig(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "%sd harness %s\n", args[0], path)
+	case "remove":
+		if !a.cfg.IsCustomHarness(path) {
+			return fmt.Errorf("harness %s is not a configured custom harness", path)
+		}
+		a.cfg.RemoveHarness(path)
+		if err := a.saveConfig(); err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "removed harness %s\n", path)
+	default:
+		return usageErrorf("unknown harness subcommand %q", args[0])
+	}
+
+	return nil
+}
+
+func (a *app) saveConfig() error {
+	return a.cfg.Save(a.configPath)
+}
+
+func (a *app) harnesses() []string {
+	harnesses := config.MergeUnique(a.cfg.ActiveHarnesses(), a.projectHarnesses())
+	sort.Strings(harnesses)
+	return harnesses
+}
+
+func (a *app) projectHarnesses() []string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+
+	var harnesses []string
+	for _, harness := range a.cfg.DetectProjectHarnesses(dir) {
+		if a.cfg.HarnessEnabled(harness) {
+			harnesses = append(harnesses, harness)
+		}
+	}
+	return harnesses
+}
+
+func (a *app) projectInstallHarnesses() ([]string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if len(a.cfg.DetectProjectHarnesses(dir)) > 0 {
+		harnesses := a.projectHarnesses()
+		if len(harnesses) == 0 {
+			return nil, errors.New("every project harness is disabled")
+		}
+		return harnesses, nil
+	}
+
+	harness, err := a.cfg.DefaultProjectHarness(dir)
+	if err != nil {
+		return nil, err
+	}
+	return []string{harness}, nil
+}
+
+func (a *app) resolveHarness(value string) (string, error) {
+	if strings.TrimSpace(value) == "" {
+		return "", usageErrorf("--harness is required")
+	}
+	if harness, ok := a.cfg.FindHarness(value); ok {
+		return harness.Path, nil
+	}
+	return config.ExpandPath(value)
+}
+
+func (a *app) harnessConflict(harness string) install.ConflictAction {
+	if policy := a.cfg.HarnessConflict(harness); policy != config.ConflictPolicyAsk {
+		return install.ConflictAction(policy)
+	}
+	return install.ConflictSkip
+}
+
+func (a *app) resolveHarnesses(values []string) ([]string, error) {
+	if len(values) == 0 {
+		return nil, usageErrorf("--harness is required")
+	}
+
+	var harnesses []string
+	for _, value := range values {
+		if strings.TrimSpace(value) == "all" {
+			all := a.harnesses()
+			if len(all) == 0 {
+				return nil, errors.New("no harnesses configured or detected")
+			}
+			harnesses = append(harnesses, all...)
+			continue
+		}
+		harness, err := a.resolveHarness(value)
+		if err != nil {
+			return nil, err
+		}
+		harnesses = append(harnesses, harness)
+	}
+	return config.MergeUnique(harnesses), nil
+}
+
+func (a *app) resolveRegistry(identifier string) (config.Registry, error) {
+	trimmed := strings.TrimSpace(identifier)
+
+	var matches []config.Registry
+	for _, registry := range a.cfg.Registries {
+		if registry.ID == trimmed || registry.Source == trimmed {
+			return registry, nil
+		}
+		if registry.DisplayName() == trimmed || (!registry.IsRemote() && filepath.Base(registry.Source) == trimmed) {
+			matches = append(matches, registry)
+		}
+	}
+
+	if len(matches) == 0 {
+		if expanded, err := config.ExpandPath(trimmed); err == nil {
+			for _, registry := range a.cfg.Registries {
+				if registry.Type == config.RegistryTypeLocal && registry.Source == expanded {
+					return registry, nil
+				}
+			}
+		}
+		return config.Registry{}, fmt.Errorf("registry not found: %s", trimmed)
+	}
+	if len(matches) > 1 {
+		return config.Registry{}, fmt.Errorf("registry name %q is ambiguous, use the registry id", trimmed)
+	}
+	return matches[0], nil
+}
+
+func (a *app) resolveRegistrySkill(reference string) (config.Registry, scan.Skill, error) {
+	idx := strings.LastIndex(reference, "/")
+	if idx <= 0 || idx >= len(reference)-1 {
+		return config.Registry{}, scan.Skill{}, usageErrorf("expected <registry>/<skill>, got %q", reference)
+	}
+
+	registry, err := a.resolveRegistry(reference[:idx])
+	if err != nil {
+		return config.Registry{}, scan.Skill{}, err
+	}
+
+	skills, err := scanRegistry(registry)
+	if err != nil {
+		return config.Registry{}, scan.Skill{}, err
+	}
+
+	skillName := reference[idx+1:]
+	var matches []scan.Skill
+	for _, skill := range skills {
+		if skill.Name == skillName {
+			matches = append(matches, skill)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return config.Registry{}, scan.Skill{}, fmt.Errorf("skill %s not found in registry %s", skillName, registry.DisplayName())
+	case 1:
+		return registry, matches[0], nil
+	default:
+		paths := make([]string, 0, len(matches))
+		for _, match := range matches {
+			paths = append(paths, match.Path)
+		}
+		return config.Registry{}, scan.Skill{}, fmt.Errorf("skill name %s is ambiguous in registry %s: %s", skillName, registry.DisplayName(), strings.Join(paths, ", "))
+	}
+}
+
+func scanRegistry(registry config.Registry) ([]scan.Skill, error) {
+	skills, root, status, err := scanRegistryStatus(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case "not synced":
+		return nil, fmt.Errorf("registry %s is not synced, run skiller sync", registry.DisplayName())
+	case "missing":
+		return nil, fmt.Errorf("registry path does not exist: %s", root)
+	}
+	return skills, nil
+}
+
+func parseConflictAction(value string) (install.ConflictAction, error) {
+	switch action := install.ConflictAction(strings.TrimSpace(value)); action {
+	case install.ConflictSkip, install.ConflictOverwrite, install.ConflictRename:
+		return action, nil
+	default:
+		return "", usageErrorf("unknown conflict action %q", value)
+	}
+}
+
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func newFlagSet(name string, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	return fs
+}
+
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %v", ErrUsage, err)
+		}
+
+		remaining := fs.Args()
+		if len(remaining) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, remaining[0])
+		args = remaining[1:]
+	}
+}
+
+func stripOffline(args []string) ([]string, bool) {
+	kept := make([]string, 0, len(args))
+	offline := false
+	for _, arg := range args {
+		if arg == OfflineFlag {
+			offline = true
+			continue
+		}
+		kept = append(kept, arg)
+	}
+	return kept, offline
+}
+
+func usageErrorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrUsage, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,448 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"skiller/internal/registrysync"
+)
+
+func setupEnv(t *testing.T) (string, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	t.Setenv("HOME", filepath.Join(root, "home"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(root, "cache"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(root, "state"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+
+	registry := filepath.Join(root, "registry")
+	if err := os.MkdirAll(filepath.Join(registry, "nested", "alpha"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(registry, "nested", "alpha", "SKILL.md"), []byte("# alpha"), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+
+	return registry, filepath.Join(root, "harness")
+}
+
+func runCLI(t *testing.T, args ...string) (string, string, int) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := Run(args, &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestInstallListUninstall(t *testing.T) {
+	registry, harness := setupEnv(t)
+
+	if _, stderr, code := runCLI(t, "registry", "add", registry); code != 0 {
+		t.Fatalf("registry add failed (%d): %s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "harness", "add", harness); code != 0 {
+		t.Fatalf("harness add failed (%d): %s", code, stderr)
+	}
+
+	if _, stderr, code := runCLI(t, "install", "registry/alpha", "--harness", harness); code != 0 {
+		t.Fatalf("install failed (%d): %s", code, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(harness, "alpha", "SKILL.md")); err != nil {
+		t.Fatalf("expected installed skill: %v", err)
+	}
+
+	stdout, stderr, code := runCLI(t, "list", "installed")
+	if code != 0 {
+		t.Fatalf("list failed (%d): %s", code, stderr)
+	}
+	if !strings.Contains(stdout, harness+"  alpha") {
+		t.Fatalf("expected list to include installed skill, got:\n%s", stdout)
+	}
+
+	if _, stderr, code := runCLI(t, "uninstall", "alpha", "--harness", harness); code != 0 {
+		t.Fatalf("uninstall failed (%d): %s", code, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(harness, "alpha")); !os.IsNotExist(err) {
+		t.Fatalf("expected skill to be removed")
+	}
+}
+
+func TestTrashRestoresUninstalledSkill(t *testing.T) {
+	registry, harness := setupEnv(t)
+
+	if _, stderr, code := runCLI(t, "registry", "add", registry); code != 0 {
+		t.Fatalf("registry add failed (%d): %s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "install", "registry/alpha", "--harness", harness); code != 0 {
+		t.Fatalf("install failed (%d): %s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "uninstall", "alpha", "--harness", harness); code != 0 {
+		t.Fatalf("uninstall failed (%d): %s", code, stderr)
+	}
+
+	stdout, stderr, code := runCLI(t, "trash", "list", "--json")
+	if code != 0 {
+		t.Fatalf("trash list failed (%d): %s", code, stderr)
+	}
+	var doc struct {
+		Entries []struct {
+			ID           string `json:"id"`
+			OriginalPath string `json:"original_path"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &doc); err != nil || len(doc.Entries) != 1 {
+		t.Fatalf("expected one trash entry: %v\n%s", err, stdout)
+	}
+	if doc.Entries[0].OriginalPath != filepath.Join(harness, "alpha") {
+		t.Fatalf("unexpected original path %s", doc.Entries[0].OriginalPath)
+	}
+
+	if _, stderr, code := runCLI(t, "trash", "restore", doc.Entries[0].ID); code != 0 {
+		t.Fatalf("trash restore failed (%d): %s", code, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(harness, "alpha", "SKILL.md")); err != nil {
+		t.Fatalf("expected restored skill: %v", err)
+	}
+
+	if _, _, code := runCLI(t, "trash", "empty", "--older-than", "soon"); code != 2 {
+		t.Fatalf("expected usage error for invalid age, got %d", code)
+	}
+}
+
+func TestInstallRequiresHarness(t *testing.T) {
+	registry, _ := setupEnv(t)
+
+	if _, stderr, code := runCLI(t, "registry", "add", registry); code != 0 {
+		t.Fatalf("registry add failed (%d): %s", code, stderr)
+	}
+
+	_, stderr, code := runCLI(t, "install", "registry/alpha")
+	if code != 2 {
+		t.Fatalf("expected usage error exit code, got %d", code)
+	}
+	if !strings.Contains(stderr, "--harness is required") {
+		t.Fatalf("unexpected stderr: %s", stderr)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	setupEnv(t)
+
+	if _, _, code := runCLI(t, "bogus"); code != 2 {
+		t.Fatalf("expected exit code 2 for unknown command, got %d", code)
+	}
+}
+
+func TestUpgradeReinstallsOutdatedSkills(t *testing.T) {
+	registry, harness := setupEnv(t)
+
+	if _, stderr, code := runCLI(t, "registry", "add", registry); code != 0 {
+		t.Fatalf("registry add failed (%d): %s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "install", "registry/alpha", "--harness", harness); code != 0 {
+		t.Fatalf("install failed (%d): %s", code, stderr)
+	}
+
+	if err := os.WriteFile(filepath.Join(registry, "nested", "alpha", "SKILL.md"), []byte("# alpha v2"), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+
+	stdout, _, _ := runCLI(t, "list", "installed", "--harness", harness)
+	if !strings.Contains(stdout, "outdated") {
+		t.Fatalf("expected outdated state, got:\n%s", stdout)
+	}
+
+	if _, stderr, code := runCLI(t, "harness", "add", harness); code != 0 {
+		t.Fatalf("harness add failed (%d): %s", code, stderr)
+	}
+	stdout, stderr, code := runCLI(t, "upgrade", "--all")
+	if code != 0 || !strings.Contains(stdout, "upgraded") {
+		t.Fatalf("upgrade failed (%d): %s%s", code, stdout, stderr)
+	}
+
+	content, err := os.ReadFile(filepath.Join(harness, "alpha", "SKILL.md"))
+	if err != nil || string(content) != "# alpha v2" {
+		t.Fatalf("expected upgraded skill, got %q (%v)", content, err)
+	}
+}
+
+func TestUpgradeReportsSkippedModifiedSkills(t *testing.T) {
+	registry, harness := setupEnv(t)
+
+	if _, stderr, code := runCLI(t, "registry", "add", registry); code != 0 {
+		t.Fatalf("registry add failed (%d): %s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "install", "registry/alpha", "--harness", harness); code != 0 {
+		t.Fatalf("install failed (%d): %s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "harness", "add", harness); code != 0 {
+		t.Fatalf("harness add failed (%d): %s", code, stderr)
+	}
+
+	if err := os.WriteFile(filepath.Join(harness, "alpha", "SKILL.md"), []byte("# alpha, edited"), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(registry, "nested", "alpha", "SKILL.md"), []byte("# alpha v2"), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+
+	stdout, stderr, code := runCLI(t, "upgrade", "--all")
+	if code != 0 || !strings.Contains(stderr, "locally modified") {
+		t.Fatalf("expected the modified skill to be skipped (%d): %s%s", code, stdout, stderr)
+	}
+	if !strings.Contains(stdout, "1 skipped (locally modified; use --force)") || strings.Contains(stdout, "up to date") {
+		t.Fatalf("expected a skipped count instead of up to date, got:\n%s", stdout)
+	}
+
+	content, err := os.ReadFile(filepath.Join(harness, "alpha", "SKILL.md"))
+	if err != nil || string(content) != "# alpha, edited" {
+		t.Fatalf("expected the local edit to survive, got %q (%v)", content, err)
+	}
+}
+
+func TestInstallIntoSeveralHarnesses(t *testing.T) {
+	registry, harness := setupEnv(t)
+	second := harness + "-second"
+
+	if _, stderr, code := runCLI(t, "registry", "add", registry); code != 0 {
+		t.Fatalf("registry add failed (%d): %s", code, stderr)
+	}
+
+	stdout, stderr, code := runCLI(t, "install", "registry/alpha", "--harness", harness, "--harness", second)
+	if code != 0 {
+		t.Fatalf("install failed (%d): %s", code, stderr)
+	}
+	for _, path := range []string{harness, second} {
+		if _, err := os.Stat(filepath.Join(path, "alpha", "SKILL.md")); err != nil {
+			t.Fatalf("expected skill in %s: %v", path, err)
+		}
+		if !strings.Contains(stdout, filepath.Join(path, "alpha")) {
+			t.Fatalf("expected a result line for %s, got:\n%s", path, stdout)
+		}
+	}
+
+	for _, path := range []string{harness, second} {
+		if _, stderr, code := runCLI(t, "harness", "add", path); code != 0 {
+			t.Fatalf("harness add failed (%d): %s", code, stderr)
+		}
+	}
+	stdout, stderr, code = runCLI(t, "install", "registry/alpha", "--harness", "all")
+	if code != 0 {
+		t.Fatalf("install --harness all failed (%d): %s", code, stderr)
+	}
+	if strings.Count(stdout, "skipped alpha") != 2 {
+		t.Fatalf("expected a skip per harness, got:\n%s", stdout)
+	}
+}
+
+func TestNamedHarnessUsesItsConflictPolicy(t *testing.T) {
+	registry, harness := setupEnv(t)
+
+	if _, stderr, code := runCLI(t, "registry", "add", registry); code != 0 {
+		t.Fatalf("registry add failed (%d): %s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "harness", "add", harness, "--name", "work", "--conflict", "overwrite"); code != 0 {
+		t.Fatalf("harness add failed (%d): %s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "install", "registry/alpha", "--harness", "work"); code != 0 {
+		t.Fatalf("install failed (%d): %s", code, stderr)
+	}
+
+	stdout, stderr, code := runCLI(t, "install", "registry/alpha", "--harness", "work")
+	if code != 0 || !strings.Contains(stdout, "previous version moved to trash") {
+		t.Fatalf("expected the harness policy to overwrite (%d): %s%s", code, stdout, stderr)
+	}
+
+	if _, stderr, code := runCLI(t, "harness", "disable", "work"); code != 0 {
+		t.Fatalf("harness disable failed (%d): %s", code, stderr)
+	}
+	stdout, _, _ = runCLI(t, "list", "installed")
+	if strings.Contains(stdout, "alpha") {
+		t.Fatalf("expected disabled harness to be hidden, got:\n%s", stdout)
+	}
+}
+
+func TestInstallIntoProjectHarness(t *testing.T) {
+	registry, _ := setupEnv(t)
+
+	project := filepath.Join(t.TempDir(), "project")
+	if err := os.MkdirAll(filepath.Join(project, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(project, "src"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	t.Chdir(filepath.Join(project, "src"))
+
+	if _, stderr, code := runCLI(t, "registry", "add", registry); code != 0 {
+		t.Fatalf("registry add failed (%d): %s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "install", "registry/alpha", "--project"); code != 0 {
+		t.Fatalf("install --project failed (%d): %s", code, stderr)
+	}
+
+	harness := filepath.Join(project, ".claude", "skills")
+	if _, err := os.Stat(filepath.Join(harness, "alpha", "SKILL.md")); err != nil {
+		t.Fatalf("expected skill in project harness: %v", err)
+	}
+
+	stdout, stderr, code := runCLI(t, "list", "installed", "--json")
+	if code != 0 {
+		t.Fatalf("list failed (%d): %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"project": true`) {
+		t.Fatalf("expected project harness in list, got:\n%s", stdout)
+	}
+}
+
+func TestOfflineSkipsSync(t *testing.T) {
+	setupEnv(t)
+	t.Cleanup(func() { registrysync.SetOffline(false) })
+
+	if _, stderr, code := runCLI(t, "registry", "add", "https://example.invalid/acme/skills.git"); code != 0 {
+		t.Fatalf("registry add failed (%d): %s", code, stderr)
+	}
+
+	_, stderr, code := runCLI(t, "--offline", "sync")
+	if code != 1 || !strings.Contains(stderr, "offline mode is on") {
+		t.Fatalf("expected offline sync to be refused, got %d: %s", code, stderr)
+	}
+
+	stdout, stderr, code := runCLI(t, "--offline", "list", "registries")
+	if code != 0 || !strings.Contains(stdout, "not synced") {
+		t.Fatalf("expected offline list from the cache, got %d: %s%s", code, stdout, stderr)
+	}
+
+	registrysync.SetOffline(false)
+	_, stderr, code = runCLI(t, "sync", "--offline")
+	if code != 1 || !strings.Contains(stderr, "offline mode is on") {
+		t.Fatalf("expected --offline after the command to be honored, got %d: %s", code, stderr)
+	}
+
+	registrysync.SetOffline(false)
+	stdout, stderr, code = runCLI(t, "list", "registries", "--offline", "--json")
+	if code != 0 || !strings.Contains(stdout, "not synced") {
+		t.Fatalf("expected offline list after the command, got %d: %s%s", code, stdout, stderr)
+	}
+}
+
+func gitCommit(t *testing.T, repo, name, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(repo, name)), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	for _, args := range [][]string{{"add", "-A"}, {"commit", "--quiet", "-m", "update " + name}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=skiller", "GIT_AUTHOR_EMAIL=skiller@example.com",
+			"GIT_COMMITTER_NAME=skiller", "GIT_COMMITTER_EMAIL=skiller@example.com",
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v (%s)", strings.Join(args, " "), err, output)
+		}
+	}
+}
+
+func TestSyncJSONReportsSkillChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	setupEnv(t)
+
+	upstream := filepath.Join(t.TempDir(), "skills.git")
+	if output, err := exec.Command("git", "init", "--quiet", "--initial-branch=main", upstream).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v (%s)", err, output)
+	}
+	gitCommit(t, upstream, "alpha/SKILL.md", "# alpha")
+
+	if _, stderr, code := runCLI(t, "registry", "add", "file://"+upstream); code != 0 {
+		t.Fatalf("registry add failed (%d): %s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "sync"); code != 0 {
+		t.Fatalf("first sync failed (%d): %s", code, stderr)
+	}
+
+	gitCommit(t, upstream, "alpha/SKILL.md", "# alpha v2")
+	gitCommit(t, upstream, "beta/SKILL.md", "# beta")
+
+	stdout, stderr, code := runCLI(t, "sync", "--json")
+	if code != 0 {
+		t.Fatalf("sync failed (%d): %s", code, stderr)
+	}
+	var doc struct {
+		SchemaVersion int `json:"schema_version"`
+		Registries    []struct {
+			Status         string   `json:"status"`
+			Commit         string   `json:"commit"`
+			PreviousCommit string   `json:"previous_commit"`
+			CommitsAhead   int      `json:"commits_ahead"`
+			Added          []string `json:"added"`
+			Changed        []string `json:"changed"`
+			Stat           string   `json:"stat"`
+		} `json:"registries"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &doc); err != nil || len(doc.Registries) != 1 {
+		t.Fatalf("expected one synced registry, got %v:\n%s", err, stdout)
+	}
+	synced := doc.Registries[0]
+	if doc.SchemaVersion != SchemaVersion || synced.Status != "synced" || synced.CommitsAhead != 2 || synced.PreviousCommit == "" || synced.Commit == synced.PreviousCommit {
+		t.Fatalf("unexpected sync report:\n%s", stdout)
+	}
+	if len(synced.Added) != 1 || synced.Added[0] != "beta" || len(synced.Changed) != 1 || synced.Changed[0] != "alpha" {
+		t.Fatalf("expected beta added and alpha changed, got:\n%s", stdout)
+	}
+	if !strings.Contains(synced.Stat, "beta/SKILL.md") {
+		t.Fatalf("expected a diff stat of the skill folders, got:\n%s", stdout)
+	}
+}
+
+func TestDiffComparesInstalledSkillWithRegistry(t *testing.T) {
+	registry, harness := setupEnv(t)
+
+	if _, stderr, code := runCLI(t, "registry", "add", registry); code != 0 {
+		t.Fatalf("registry add failed (%d): %s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "install", "registry/alpha", "--harness", harness); code != 0 {
+		t.Fatalf("install failed (%d): %s", code, stderr)
+	}
+
+	stdout, stderr, code := runCLI(t, "diff", "alpha", "--harness", harness)
+	if code != 0 || !strings.Contains(stdout, "matches") {
+		t.Fatalf("expected a fresh install to match, got %d: %s%s", code, stdout, stderr)
+	}
+
+	if err := os.WriteFile(filepath.Join(harness, "alpha", "SKILL.md"), []byte("# alpha, edited\n"), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(harness, "alpha", "local.md"), []byte("mine\n"), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	for _, reference := range []string{"alpha", "registry/alpha"} {
+		stdout, stderr, code := runCLI(t, "diff", reference, "--harness", harness)
+		if code != 0 {
+			t.Fatalf("diff %s failed (%d): %s", reference, code, stderr)
+		}
+		for _, want := range []string{"1 changed, 1 removed", "changed  SKILL.md", "removed  local.md", "--- a/SKILL.md\n+++ b/SKILL.md\n@@ -1 +1 @@\n-# alpha, edited\n+# alpha\n"} {
+			if !strings.Contains(stdout, want) {
+				t.Fatalf("diff %s: expected %q in:\n%s", reference, want, stdout)
+			}
+		}
+	}
+
+	if _, stderr, code := runCLI(t, "diff", "missing", "--harness", harness); code != 1 || !strings.Contains(stderr, "not installed") {
+		t.Fatalf("expected a missing skill to fail, got %d: %s", code, stderr)
+	}
+}
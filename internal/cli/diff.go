@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"skiller/internal/registrysync"
+	"skiller/internal/scan"
+	"skiller/internal/skilldiff"
+)
+
+func runDiff(a *app, args []string) error {
+	fs := newFlagSet("diff", a.stderr)
+	harnessFlag := fs.String("harness", "", "harness path the skill is installed in")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("expected exactly one <skill> or <registry>/<skill> argument")
+	}
+
+	harness, err := a.resolveHarness(*harnessFlag)
+	if err != nil {
+		return err
+	}
+	installed, source, err := a.diffSources(harness, positional[0])
+	if err != nil {
+		return err
+	}
+
+	files, err := skilldiff.Compare(installed, source)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(a.stdout, "%s matches %s\n", installed, source)
+		return nil
+	}
+
+	fmt.Fprintf(a.stdout, "%s -> %s: %s\n", installed, source, skilldiff.Summary(files))
+	for _, file := range files {
+		line := fmt.Sprintf("  %-8s %s", file.Status, file.Path)
+		if file.Binary {
+			line += " (binary)"
+		}
+		fmt.Fprintln(a.stdout, line)
+	}
+	for _, file := range files {
+		if file.Diff != "" {
+			fmt.Fprintf(a.stdout, "\n%s", file.Diff)
+		}
+	}
+	return nil
+}
+
+func (a *app) diffSources(harness, skill string) (string, string, error) {
+	if strings.Contains(skill, "/") {
+		registry, source, err := a.resolveRegistrySkill(skill)
+		if err != nil {
+			return "", "", err
+		}
+		if err := registrysync.FetchSkill(registry, source.Path); err != nil {
+			return "", "", err
+		}
+		installed := filepath.Join(harness, filepath.Base(source.Path))
+		if !isDirectory(installed) {
+			return "", "", fmt.Errorf("skill %s is not installed in %s", source.Name, harness)
+		}
+		return installed, source.Path, nil
+	}
+
+	installed, err := scan.ScanHarness(harness)
+	if err != nil {
+		return "", "", err
+	}
+	for _, candidate := range installed {
+		if candidate.Name != skill {
+			continue
+		}
+		if candidate.Adapted {
+			return "", "", fmt.Errorf("%s is rendered into a %s harness file and cannot be diffed", skill, a.cfg.HarnessFormat(harness))
+		}
+
+		status, err := a.checker().Check(harness, candidate)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", candidate.Path, err)
+		}
+		if status.Source.Path == "" {
+			return "", "", fmt.Errorf("cannot find the registry version of %s (%s), name it as <registry>/<skill>", skill, status.State)
+		}
+		if err := registrysync.FetchSkill(status.Registry, status.Source.Path); err != nil {
+			return "", "", err
+		}
+		return candidate.Path, status.Source.Path, nil
+	}
+	return "", "", fmt.Errorf("skill %s is not installed in %s", skill, harness)
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,399 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"skiller/internal/config"
+	"skiller/internal/provenance"
+	"skiller/internal/registrysync"
+	"skiller/internal/scan"
+	"skiller/internal/upgrade"
+)
+
+type listDocument struct {
+	SchemaVersion int             `json:"schema_version"`
+	Registries    *[]registryView `json:"registries,omitempty"`
+	Skills        *[]skillView    `json:"skills,omitempty"`
+	Harnesses     *[]harnessView  `json:"harnesses,omitempty"`
+}
+
+type registryView struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Source       string `json:"source"`
+	Ref          string `json:"ref,omitempty"`
+	Subdir       string `json:"subdir,omitempty"`
+	SyncInterval string `json:"sync_interval,omitempty"`
+	Status       string `json:"status"`
+	Path         string `json:"path"`
+	Error        string `json:"error,omitempty"`
+
+	Commit         string     `json:"commit,omitempty"`
+	PreviousCommit string     `json:"previous_commit,omitempty"`
+	CommitsAhead   *int       `json:"commits_ahead,omitempty"`
+	SyncedAt       *time.Time `json:"synced_at,omitempty"`
+	LastAttempt    *time.Time `json:"last_attempt,omitempty"`
+	LastSyncError  string     `json:"last_sync_error,omitempty"`
+}
+
+type skillView struct {
+	Name          string             `json:"name"`
+	Path          string             `json:"path"`
+	Link          string             `json:"link,omitempty"`
+	RegistryID    string             `json:"registry_id,omitempty"`
+	Registry      string             `json:"registry,omitempty"`
+	Metadata      *metadataView      `json:"metadata,omitempty"`
+	MetadataError string             `json:"metadata_error,omitempty"`
+	Provenance    *provenance.Record `json:"provenance,omitempty"`
+	State         string             `json:"state,omitempty"`
+}
+
+type metadataView struct {
+	Name         string   `json:"name,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	Version      string   `json:"version,omitempty"`
+	License      string   `json:"license,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	AllowedTools []string `json:"allowed_tools,omitempty"`
+}
+
+func newSkillView(skill scan.Skill) skillView {
+	view := skillView{Name: skill.Name, Path: skill.Path, Link: skill.Link}
+	if skill.MetadataErr != nil {
+		view.MetadataError = skill.MetadataErr.Error()
+	}
+
+	meta := skill.Metadata
+	if meta.Name != "" || meta.Description != "" || meta.Version != "" || meta.License != "" || len(meta.Tags) > 0 || len(meta.AllowedTools) > 0 {
+		view.Metadata = &metadataView{
+			Name:         meta.Name,
+			Description:  meta.Description,
+			Version:      meta.Version,
+			License:      meta.License,
+			Tags:         meta.Tags,
+			AllowedTools: meta.AllowedTools,
+		}
+	}
+	return view
+}
+
+type harnessView struct {
+	Name     string      `json:"name,omitempty"`
+	Path     string      `json:"path"`
+	Kind     string      `json:"kind"`
+	Custom   bool        `json:"custom"`
+	Project  bool        `json:"project,omitempty"`
+	Mode     string      `json:"mode"`
+	Conflict string      `json:"conflict,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Skills   []skillView `json:"skills"`
+}
+
+func runList(a *app, args []string) error {
+	fs := newFlagSet("list", a.stderr)
+	registryFlag := fs.String("registry", "", "only list skills of this registry")
+	harnessFlag := fs.String("harness", "", "only list installs of this harness")
+	formats := addFormatFlags(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return usageErrorf("expected at most one of registries, skills or installed")
+	}
+
+	format, err := formats.resolve()
+	if err != nil {
+		return err
+	}
+
+	section := "all"
+	if len(positional) == 1 {
+		section = positional[0]
+	}
+
+	doc := listDocument{SchemaVersion: SchemaVersion}
+	switch section {
+	case "all":
+		registries, skills, err := a.registryViews(*registryFlag)
+		if err != nil {
+			return err
+		}
+		harnesses, err := a.harnessViews(*harnessFlag)
+		if err != nil {
+			return err
+		}
+		doc.Registries = &registries
+		doc.Skills = &skills
+		doc.Harnesses = &harnesses
+	case "registries":
+		registries, _, err := a.registryViews(*registryFlag)
+		if err != nil {
+			return err
+		}
+		doc.Registries = &registries
+	case "skills":
+		_, skills, err := a.registryViews(*registryFlag)
+		if err != nil {
+			return err
+		}
+		doc.Skills = &skills
+	case "installed":
+		harnesses, err := a.harnessViews(*harnessFlag)
+		if err != nil {
+			return err
+		}
+		doc.Harnesses = &harnesses
+	default:
+		return usageErrorf("unknown list section %q", section)
+	}
+
+	return writeDocument(a.stdout, format, doc, func(tw *tabwriter.Writer) {
+		writeListTable(tw, doc)
+	})
+}
+
+func writeListTable(tw *tabwriter.Writer, doc listDocument) {
+	sections := 0
+	separate := func() {
+		if sections > 0 {
+			fmt.Fprintln(tw)
+		}
+		sections++
+	}
+
+	if doc.Registries != nil {
+		separate()
+		fmt.Fprintln(tw, "ID\tTYPE\tNAME\tSTATUS\tCOMMIT\tSOURCE")
+		for _, registry := range *doc.Registries {
+			source := registry.Source
+			if registry.Ref != "" {
+				source += "#" + registry.Ref
+			}
+			commit := "-"
+			if len(registry.Commit) > 7 {
+				commit = registry.Commit[:7]
+			}
+			status := registry.Status
+			if status == "cached" && registry.SyncedAt != nil {
+				status += " " + registrysync.FormatAge(time.Since(*registry.SyncedAt))
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", registry.ID, registry.Type, registry.Name, status, commit, source)
+		}
+	}
+
+	if doc.Skills != nil {
+		separate()
+		fmt.Fprintln(tw, "REGISTRY\tSKILL\tVERSION\tDESCRIPTION")
+		for _, skill := range *doc.Skills {
+			version, description := "-", "-"
+			if skill.Metadata != nil {
+				if skill.Metadata.Version != "" {
+					version = skill.Metadata.Version
+				}
+				if skill.Metadata.Description != "" {
+					description = tableText(skill.Metadata.Description, 60)
+				}
+			}
+			if skill.MetadataError != "" {
+				description = "invalid frontmatter"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", skill.Registry, skill.Name, version, description)
+		}
+	}
+
+	if doc.Harnesses != nil {
+		separate()
+		fmt.Fprintln(tw, "HARNESS\tSKILL\tSTATE\tFROM\tINSTALLED\tPATH")
+		for _, harness := range *doc.Harnesses {
+			label := harness.Path
+			if harness.Name != "" {
+				label = harness.Name
+			}
+			if harness.Error != "" {
+				fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t%s\n", label, harness.Error)
+				continue
+			}
+			if len(harness.Skills) == 0 {
+				fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t-\n", label)
+				continue
+			}
+			for _, skill := range harness.Skills {
+				from, installedAt := "-", "-"
+				if skill.Provenance != nil {
+					from = skill.Provenance.Summary()
+					installedAt = skill.Provenance.InstalledAt.Local().Format("2006-01-02 15:04")
+				}
+				if skill.Link != "" {
+					from = "-> " + skill.Link
+				}
+				state := skill.State
+				if state == "" {
+					state = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", label, skill.Name, state, from, installedAt, skill.Path)
+			}
+		}
+	}
+}
+
+func (a *app) registryViews(filter string) ([]registryView, []skillView, error) {
+	registries := a.cfg.Registries
+	if filter != "" {
+		registry, err := a.resolveRegistry(filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		registries = []config.Registry{registry}
+	}
+
+	state, err := registrysync.LoadState()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	registryOut := make([]registryView, 0, len(registries))
+	skillOut := make([]skillView, 0)
+	for _, registry := range registries {
+		view := registryView{
+			ID:           registry.ID,
+			Name:         registry.DisplayName(),
+			Type:         string(registry.Type),
+			Source:       registry.Source,
+			Ref:          registry.Ref,
+			Subdir:       registry.Subdir,
+			SyncInterval: registry.SyncInterval,
+		}
+
+		if synced, ok := state.Registries[registry.ID]; ok && registry.IsRemote() {
+			view.Commit = synced.Commit
+			view.PreviousCommit = synced.PreviousCommit
+			if synced.CommitsAhead >= 0 && synced.PreviousCommit != "" {
+				ahead := synced.CommitsAhead
+				view.CommitsAhead = &ahead
+			}
+			if !synced.SyncedAt.IsZero() {
+				syncedAt := synced.SyncedAt
+				view.SyncedAt = &syncedAt
+			}
+			if !synced.LastAttempt.IsZero() {
+				lastAttempt := synced.LastAttempt
+				view.LastAttempt = &lastAttempt
+			}
+			view.LastSyncError = synced.LastError
+		}
+
+		skills, root, status, err := scanRegistryStatus(registry)
+		view.Path = root
+		view.Status = status
+		if err != nil {
+			view.Error = err.Error()
+		}
+		registryOut = append(registryOut, view)
+
+		for _, skill := range skills {
+			view := newSkillView(skill)
+			view.RegistryID = registry.ID
+			view.Registry = registry.DisplayName()
+			skillOut = append(skillOut, view)
+		}
+	}
+
+	return registryOut, skillOut, nil
+}
+
+func (a *app) harnessViews(filter string) ([]harnessView, error) {
+	harnesses := a.harnesses()
+	if filter != "" {
+		harness, err := a.resolveHarness(filter)
+		if err != nil {
+			return nil, err
+		}
+		harnesses = []string{harness}
+	}
+
+	project := map[string]bool{}
+	for _, harness := range a.projectHarnesses() {
+		project[harness] = true
+	}
+
+	checker := a.checker()
+	out := make([]harnessView, 0, len(harnesses))
+	for _, harness := range harnesses {
+		profile, configured := a.cfg.Harness(harness)
+		if !configured {
+			profile = config.Harness{Path: harness, Kind: a.cfg.KindForPath(harness)}
+		}
+		view := harnessView{
+			Name:     profile.Name,
+			Path:     harness,
+			Kind:     string(profile.Kind),
+			Custom:   a.cfg.IsCustomHarness(harness),
+			Project:  project[harness],
+			Mode:     string(a.cfg.HarnessMode(harness)),
+			Conflict: string(profile.Conflict),
+			Error:    profile.Problem,
+			Skills:   []skillView{},
+		}
+
+		skills, err := scan.ScanHarness(harness)
+		if err != nil {
+			view.Error = err.Error()
+		}
+		for _, skill := range skills {
+			installed := newSkillView(skill)
+			if status, err := checker.Check(harness, skill); err == nil {
+				installed.State = string(status.State)
+				if status.State != upgrade.StateUnmanaged && status.State != upgrade.StateLinked && status.State != upgrade.StateAdapted {
+					record := status.Record
+					installed.Provenance = &record
+				}
+			}
+			view.Skills = append(view.Skills, installed)
+		}
+		out = append(out, view)
+	}
+
+	return out, nil
+}
+
+func scanRegistryStatus(registry config.Registry) ([]scan.Skill, string, string, error) {
+	root, err := config.RegistryScanRoot(registry)
+	if err != nil {
+		return nil, "", "error", err
+	}
+
+	if _, err := os.Stat(root); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			if registry.IsRemote() {
+				return nil, root, "not synced", nil
+			}
+			return nil, root, "missing", nil
+		}
+		return nil, root, "error", err
+	}
+
+	skills, err := scan.ScanRegistry(root)
+	if err != nil {
+		return nil, root, "error", err
+	}
+
+	if registry.IsRemote() {
+		return skills, root, "cached", nil
+	}
+	return skills, root, "ready", nil
+}
+
+func tableText(text string, width int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= width {
+		return string(runes)
+	}
+	return string(runes[:width-3]) + "..."
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"skiller/internal/config"
+	"skiller/internal/lockfile"
+	"skiller/internal/registrysync"
+)
+
+func runLock(a *app, args []string) error {
+	fs := newFlagSet("lock", a.stderr)
+	manifestFlag := fs.String("manifest", lockfile.ManifestFileName, "path to the project manifest")
+	interactive := fs.Bool("interactive", false, "allow git to prompt for credentials")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("lock takes no arguments")
+	}
+
+	manifestPath, err := filepath.Abs(*manifestFlag)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := lockfile.LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(manifestPath)
+	lock, err := lockfile.Resolve(manifest, dir, syncFetcher(*interactive), a.cfg.HarnessFormat)
+	if err != nil {
+		return err
+	}
+
+	lockPath := filepath.Join(dir, lockfile.LockFileName)
+	if err := lock.Save(lockPath); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stdout, "locked %d skills in %s\n", len(lock.Skills), lockPath)
+	return nil
+}
+
+func installFrozen(a *app, lockPath string, interactive bool) error {
+	absolute, err := filepath.Abs(lockPath)
+	if err != nil {
+		return err
+	}
+
+	lock, err := lockfile.LoadLock(absolute)
+	if err != nil {
+		return err
+	}
+
+	installed, err := lockfile.InstallFrozen(lock, filepath.Dir(absolute), syncFetcher(interactive), a.cfg.HarnessFormat)
+	for _, item := range installed {
+		fmt.Fprintf(a.stdout, "installed %s/%s into %s\n", item.Locked.Registry, item.Locked.Skill, item.Result.Destination)
+	}
+	return err
+}
+
+func syncFetcher(interactive bool) lockfile.Fetcher {
+	return func(registry config.Registry) error {
+		if registrysync.Offline() {
+			return nil
+		}
+		_, err := registrysync.SyncRegistry(registry, interactive, syncTimeout)
+		return err
+	}
+}
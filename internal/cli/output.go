@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const SchemaVersion = 1
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+type formatFlags struct {
+	format *string
+	json   *bool
+}
+
+func addFormatFlags(fs *flag.FlagSet) *formatFlags {
+	return &formatFlags{
+		format: fs.String("format", formatTable, "output format: table, json or yaml"),
+		json:   fs.Bool("json", false, "shorthand for --format=json"),
+	}
+}
+
+func (f *formatFlags) resolve() (string, error) {
+	if *f.json {
+		return formatJSON, nil
+	}
+
+	switch format := strings.ToLower(strings.TrimSpace(*f.format)); format {
+	case formatTable, formatJSON, formatYAML:
+		return format, nil
+	default:
+		return "", usageErrorf("unknown output format %q", *f.format)
+	}
+}
+
+func writeDocument(w io.Writer, format string, doc any, table func(tw *tabwriter.Writer)) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(doc)
+	case formatYAML:
+		encoded, err := encodeYAML(doc)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(encoded)
+		return err
+	default:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		table(tw)
+		return tw.Flush()
+	}
+}
+
+func encodeYAML(doc any) ([]byte, error) {
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	root, err := decodeYAMLNode(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	writeYAMLNode(&out, root, 0, false)
+	return out.Bytes(), nil
+}
+
+type yamlNode struct {
+	keys   []string
+	values []*yamlNode
+	list   bool
+	scalar string
+	isMap  bool
+}
+
+func decodeYAMLNode(decoder *json.Decoder) (*yamlNode, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch typed := token.(type) {
+	case json.Delim:
+		node := &yamlNode{isMap: typed == '{', list: typed == '['}
+		for decoder.More() {
+			if node.isMap {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, fmt.Sprint(keyToken))
+			}
+			child, err := decodeYAMLNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.values = append(node.values, child)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yamlNode{scalar: yamlString(typed)}, nil
+	case json.Number:
+		return &yamlNode{scalar: typed.String()}, nil
+	case bool:
+		return &yamlNode{scalar: strconv.FormatBool(typed)}, nil
+	case nil:
+		return &yamlNode{scalar: "null"}, nil
+	default:
+		return nil, fmt.Errorf("unexpected json token %v", token)
+	}
+}
+
+func writeYAMLNode(out *bytes.Buffer, node *yamlNode, indent int, inline bool) {
+	pad := strings.Repeat("  ", indent)
+
+	switch {
+	case node.isMap && len(node.values) == 0:
+		out.WriteString("{}\n")
+	case node.list && len(node.values) == 0:
+		out.WriteString("[]\n")
+	case node.isMap:
+		for i, key := range node.keys {
+			if i > 0 || !inline {
+				out.WriteString(pad)
+			}
+			out.WriteString(yamlString(key) + ":")
+			writeYAMLChild(out, node.values[i], indent+1)
+		}
+	case node.list:
+		for i, value := range node.values {
+			if i > 0 || !inline {
+				out.WriteString(pad)
+			}
+			out.WriteString("- ")
+			if value.isMap && len(value.values) > 0 {
+				writeYAMLNode(out, value, indent+1, true)
+				continue
+			}
+			if value.list && len(value.values) > 0 {
+				out.WriteString("\n")
+				writeYAMLNode(out, value, indent+1, false)
+				continue
+			}
+			writeYAMLNode(out, value, indent+1, true)
+		}
+	default:
+		out.WriteString(node.scalar + "\n")
+	}
+}
+
+func writeYAMLChild(out *bytes.Buffer, node *yamlNode, indent int) {
+	if (node.isMap || node.list) && len(node.values) > 0 {
+		out.WriteString("\n")
+		writeYAMLNode(out, node, indent, false)
+		return
+	}
+	out.WriteString(" ")
+	writeYAMLNode(out, node, indent, true)
+}
+
+func yamlString(value string) string {
+	if value == "" {
+		return `""`
+	}
+
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return strconv.Quote(value)
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return strconv.Quote(value)
+	}
+	if strings.ContainsAny(value, ":#{}[],&*!|>'\"%@`\n\t\\") || strings.HasPrefix(value, "-") || strings.HasPrefix(value, "?") || strings.TrimSpace(value) != value {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEncodeYAMLKeepsFieldOrder(t *testing.T) {
+	doc := struct {
+		SchemaVersion int      `json:"schema_version"`
+		Name          string   `json:"name"`
+		Tags          []string `json:"tags"`
+		Empty         []string `json:"empty"`
+		Nested        []struct {
+			ID    string `json:"id"`
+			Ready bool   `json:"ready"`
+		} `json:"nested"`
+	}{
+		SchemaVersion: 1,
+		Name:          "true",
+		Tags:          []string{"a", "b: c"},
+		Empty:         []string{},
+	}
+	doc.Nested = append(doc.Nested, struct {
+		ID    string `json:"id"`
+		Ready bool   `json:"ready"`
+	}{ID: "x", Ready: true})
+
+	encoded, err := encodeYAML(doc)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	expected := `schema_version: 1
+name: "true"
+tags:
+  - a
+  - "b: c"
+empty: []
+nested:
+  - id: x
+    ready: true
+`
+	if string(encoded) != expected {
+		t.Fatalf("unexpected yaml:\n%s", encoded)
+	}
+}
+
+func TestListJSONSchema(t *testing.T) {
+	registry, harness := setupEnv(t)
+
+	if _, stderr, code := runCLI(t, "registry", "add", registry); code != 0 {
+		t.Fatalf("registry add failed (%d): %s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, "install", "registry/alpha", "--harness", harness); code != 0 {
+		t.Fatalf("install failed (%d): %s", code, stderr)
+	}
+
+	stdout, stderr, code := runCLI(t, "list", "--json", "--harness", harness)
+	if code != 0 {
+		t.Fatalf("list failed (%d): %s", code, stderr)
+	}
+
+	var doc listDocument
+	if err := json.Unmarshal([]byte(stdout), &doc); err != nil {
+		t.Fatalf("invalid json output: %v\n%s", err, stdout)
+	}
+
+	if doc.SchemaVersion != SchemaVersion {
+		t.Fatalf("expected schema version %d, got %d", SchemaVersion, doc.SchemaVersion)
+	}
+	if doc.Registries == nil || len(*doc.Registries) != 1 || (*doc.Registries)[0].Status != "ready" {
+		t.Fatalf("unexpected registries: %#v", doc.Registries)
+	}
+	if doc.Skills == nil || len(*doc.Skills) != 1 || (*doc.Skills)[0].Name != "alpha" {
+		t.Fatalf("unexpected skills: %#v", doc.Skills)
+	}
+	if doc.Harnesses == nil || len(*doc.Harnesses) != 1 || len((*doc.Harnesses)[0].Skills) != 1 {
+		t.Fatalf("unexpected harnesses: %#v", doc.Harnesses)
+	}
+	if installed := (*doc.Harnesses)[0].Skills[0]; installed.Provenance == nil || installed.Provenance.SourcePath != "nested/alpha" {
+		t.Fatalf("expected provenance for installed skill: %#v", installed)
+	}
+
+	stdout, _, code = runCLI(t, "list", "registries", "--format=yaml")
+	if code != 0 || !strings.HasPrefix(stdout, "schema_version: 1\nregistries:\n") || strings.Contains(stdout, "skills:") {
+		t.Fatalf("unexpected yaml registries output (%d):\n%s", code, stdout)
+	}
+}
+
+func TestListJSONKeepsDetectedHarnessesNonCustom(t *testing.T) {
+	setupEnv(t)
+
+	if _, stderr, code := runCLI(t, "harness", "mode", "~/.claude/skills", "symlink"); code != 0 {
+		t.Fatalf("harness mode failed (%d): %s", code, stderr)
+	}
+
+	stdout, stderr, code := runCLI(t, "list", "installed", "--json", "--harness", "~/.claude/skills")
+	if code != 0 {
+		t.Fatalf("list failed (%d): %s", code, stderr)
+	}
+
+	var doc listDocument
+	if err := json.Unmarshal([]byte(stdout), &doc); err != nil {
+		t.Fatalf("invalid json output: %v\n%s", err, stdout)
+	}
+	if doc.Harnesses == nil || len(*doc.Harnesses) != 1 {
+		t.Fatalf("unexpected harnesses: %#v", doc.Harnesses)
+	}
+	if harness := (*doc.Harnesses)[0]; harness.Custom || harness.Kind != "claude" || harness.Mode != "symlink" {
+		t.Fatalf("expected a detected claude harness with a profile, got %#v", harness)
+	}
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"skiller/internal/config"
+	"skiller/internal/trash"
+)
+
+type trashDocument struct {
+	SchemaVersion int           `json:"schema_version"`
+	Entries       []trash.Entry `json:"entries"`
+}
+
+func runTrash(a *app, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("expected a trash subcommand: list, restore or empty")
+	}
+
+	switch args[0] {
+	case "list":
+		return runTrashList(a, args[1:])
+	case "restore":
+		return runTrashRestore(a, args[1:])
+	case "empty":
+		return runTrashEmpty(a, args[1:])
+	default:
+		return usageErrorf("unknown trash subcommand %q", args[0])
+	}
+}
+
+func runTrashList(a *app, args []string) error {
+	fs := newFlagSet("trash list", a.stderr)
+	formats := addFormatFlags(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("trash list takes no arguments")
+	}
+	format, err := formats.resolve()
+	if err != nil {
+		return err
+	}
+
+	entries, err := trash.List()
+	if err != nil {
+		return err
+	}
+
+	doc := trashDocument{SchemaVersion: SchemaVersion, Entries: entries}
+	return writeDocument(a.stdout, format, doc, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "ID\tSKILL\tREASON\tDELETED\tORIGINAL PATH")
+		for _, entry := range entries {
+			deleted := entry.DeletedAt.Local().Format("2006-01-02 15:04")
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", entry.ID, entry.Name, entry.Reason, deleted, entry.OriginalPath)
+		}
+	})
+}
+
+func runTrashRestore(a *app, args []string) error {
+	fs := newFlagSet("trash restore", a.stderr)
+	force := fs.Bool("force", false, "move whatever occupies the original location to the trash first")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("expected exactly one trash entry id")
+	}
+
+	entry, err := trash.Restore(positional[0], *force)
+	if err != nil {
+		if errors.Is(err, trash.ErrDestinationExists) {
+			return fmt.Errorf("%w (use --force to replace it)", err)
+		}
+		return err
+	}
+
+	fmt.Fprintf(a.stdout, "restored %s to %s\n", entry.Name, entry.OriginalPath)
+	return nil
+}
+
+func runTrashEmpty(a *app, args []string) error {
+	fs := newFlagSet("trash empty", a.stderr)
+	olderThan := fs.String("older-than", "", "only delete entries older than this age, e.g. 7d or 12h")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageErrorf("trash empty takes no arguments")
+	}
+
+	var age time.Duration
+	if *olderThan != "" {
+		if age, err = config.ParseAge(*olderThan); err != nil {
+			return usageErrorf("invalid --older-than %q: %v", *olderThan, err)
+		}
+	}
+
+	removed, err := trash.Empty(age)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "deleted %d trash entries\n", removed)
+	return nil
+}
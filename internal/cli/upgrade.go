@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"skiller/internal/scan"
+	"skiller/internal/upgrade"
+)
+
+func runUpgrade(a *app, args []string) error {
+	fs := newFlagSet("upgrade", a.stderr)
+	harnessFlag := fs.String("harness", "", "harness path to upgrade")
+	all := fs.Bool("all", false, "upgrade outdated skills in every harness")
+	force := fs.Bool("force", false, "overwrite locally modified skills")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return usageErrorf("expected at most one <skill> argument")
+	}
+
+	var harnesses []string
+	switch {
+	case *all && (*harnessFlag != "" || len(positional) > 0):
+		return usageErrorf("--all cannot be combined with --harness or a skill argument")
+	case *all:
+		harnesses = a.harnesses()
+	default:
+		harness, err := a.resolveHarness(*harnessFlag)
+		if err != nil {
+			return err
+		}
+		harnesses = []string{harness}
+	}
+
+	checker := a.checker()
+	var statuses []upgrade.Status
+	for _, harness := range harnesses {
+		installed, err := scan.ScanHarness(harness)
+		if err != nil {
+			return err
+		}
+		for _, skill := range installed {
+			if len(positional) == 1 && skill.Name != positional[0] {
+				continue
+			}
+			status, err := checker.Check(harness, skill)
+			if err != nil {
+				return fmt.Errorf("%s: %w", skill.Path, err)
+			}
+			statuses = append(statuses, status)
+		}
+	}
+
+	if len(positional) == 1 {
+		if len(statuses) == 0 {
+			return fmt.Errorf("skill %s is not installed in %s", positional[0], harnesses[0])
+		}
+		_, err := upgrade.Upgrade(statuses[0], *force)
+		if err != nil {
+			if errors.Is(err, upgrade.ErrLocallyModified) {
+				return fmt.Errorf("%w (use --force to overwrite)", err)
+			}
+			return err
+		}
+		fmt.Fprintf(a.stdout, "%s: %s\n", statuses[0].Installed.Path, upgradeOutcome(statuses[0]))
+		return nil
+	}
+
+	upgraded, skipped, failed := 0, 0, 0
+	for _, status := range statuses {
+		if status.State != upgrade.StateOutdated && status.State != upgrade.StateModified {
+			continue
+		}
+
+		if _, err := upgrade.Upgrade(status, *force); err != nil {
+			if errors.Is(err, upgrade.ErrLocallyModified) {
+				skipped++
+				fmt.Fprintf(a.stderr, "skipped %s: locally modified (use --force to overwrite)\n", status.Installed.Path)
+				continue
+			}
+			failed++
+			fmt.Fprintf(a.stderr, "failed to upgrade %s: %v\n", status.Installed.Path, err)
+			continue
+		}
+		upgraded++
+		fmt.Fprintf(a.stdout, "%s: upgraded\n", status.Installed.Path)
+	}
+
+	if skipped > 0 {
+		fmt.Fprintf(a.stdout, "%d skipped (locally modified; use --force)\n", skipped)
+	}
+	if upgraded == 0 && skipped == 0 && failed == 0 {
+		fmt.Fprintln(a.stdout, "all managed skills are up to date")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d skills failed to upgrade", failed)
+	}
+	return nil
+}
+
+func upgradeOutcome(status upgrade.Status) string {
+	if status.State == upgrade.StateUpToDate {
+		return "already up to date"
+	}
+	return "upgraded"
+}
+
+func (a *app) registrySkills() map[string][]scan.Skill {
+	out := make(map[string][]scan.Skill, len(a.cfg.Registries))
+	for _, registry := range a.cfg.Registries {
+		skills, _, _, err := scanRegistryStatus(registry)
+		if err != nil {
+			continue
+		}
+		out[registry.ID] = skills
+	}
+	return out
+}
+
+func (a *app) checker() *upgrade.Checker {
+	return upgrade.NewChecker(a.cfg.Registries, a.registrySkills())
+}
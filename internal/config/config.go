@@ -4,11 +4,15 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -18,30 +22,91 @@ const (
 	ConfigFileName = "config.toml"
 )
 
-var knownHarnessCandidates = []string{
-	"~/.config/opencode/skills",
-	"~/.claude/skills",
-	"~/.agents/skills",
-}
-
 type RegistryType string
 
 const (
-	RegistryTypeLocal RegistryType = "local"
-	RegistryTypeGit   RegistryType = "git"
+	RegistryTypeLocal   RegistryType = "local"
+	RegistryTypeGit     RegistryType = "git"
+	RegistryTypeArchive RegistryType = "archive"
+	RegistryTypeIndex   RegistryType = "index"
+)
+
+var (
+	archiveExtensions = []string{".tar.gz", ".tgz", ".tar", ".zip"}
+	indexExtensions   = []string{".json", ".toml"}
 )
 
+type InstallMode string
+
+const (
+	InstallModeCopy     InstallMode = "copy"
+	InstallModeSymlink  InstallMode = "symlink"
+	InstallModeHardlink InstallMode = "hardlink"
+)
+
+var InstallModes = []InstallMode{InstallModeCopy, InstallModeSymlink, InstallModeHardlink}
+
+func ParseInstallMode(value string) (InstallMode, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(value))
+	if trimmed == "" {
+		return InstallModeCopy, nil
+	}
+	for _, mode := range InstallModes {
+		if string(mode) == trimmed {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown install mode %q (use copy, symlink or hardlink)", value)
+}
+
 type Registry struct {
-	ID     string       `toml:"id,omitempty"`
-	Name   string       `toml:"name,omitempty"`
-	Type   RegistryType `toml:"type"`
-	Source string       `toml:"source"`
-	Ref    string       `toml:"ref,omitempty"`
-	Subdir string       `toml:"subdir,omitempty"`
+	ID           string       `toml:"id,omitempty"`
+	Name         string       `toml:"name,omitempty"`
+	Type         RegistryType `toml:"type"`
+	Source       string       `toml:"source"`
+	Ref          string       `toml:"ref,omitempty"`
+	Subdir       string       `toml:"subdir,omitempty"`
+	SyncInterval string       `toml:"sync_interval,omitempty"`
+}
+
+func (r Registry) SyncEvery() time.Duration {
+	if strings.TrimSpace(r.SyncInterval) == "" {
+		return 0
+	}
+	interval, err := ParseAge(r.SyncInterval)
+	if err != nil {
+		return 0
+	}
+	return interval
+}
+
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, errors.New("expected a positive number of days")
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if age <= 0 {
+		return 0, errors.New("expected a positive duration")
+	}
+	return age, nil
 }
 
 func (r Registry) IsRemote() bool {
-	return r.Type == RegistryTypeGit
+	switch r.Type {
+	case RegistryTypeGit, RegistryTypeArchive, RegistryTypeIndex:
+		return true
+	default:
+		return false
+	}
 }
 
 func (r Registry) DisplayName() string {
@@ -57,17 +122,36 @@ func (r Registry) DisplayName() string {
 			return trimmed[idx+1:]
 		}
 	}
+	if r.Type == RegistryTypeArchive || r.Type == RegistryTypeIndex {
+		base := path.Base(sourcePath(r.Source))
+		for _, ext := range append(archiveExtensions, indexExtensions...) {
+			if strings.HasSuffix(strings.ToLower(base), ext) && len(base) > len(ext) {
+				return base[:len(base)-len(ext)]
+			}
+		}
+	}
 	return strings.TrimSpace(r.Source)
 }
 
 type Config struct {
-	Registries []Registry `toml:"registries"`
-	Harnesses  []string   `toml:"harnesses"`
+	Offline            bool                `toml:"offline,omitempty"`
+	Registries         []Registry          `toml:"registries"`
+	Harnesses          []Harness           `toml:"harnesses"`
+	HarnessDefinitions []HarnessDefinition `toml:"harness_definitions,omitempty"`
+}
+
+type configV3 struct {
+	Offline            bool                `toml:"offline"`
+	Registries         []Registry          `toml:"registries"`
+	Harnesses          []harnessTable      `toml:"harnesses"`
+	HarnessDefinitions []HarnessDefinition `toml:"harness_definitions"`
 }
 
 type configV2 struct {
-	Registries []Registry `toml:"registries"`
-	Harnesses  []string   `toml:"harnesses"`
+	Offline      bool                   `toml:"offline"`
+	Registries   []Registry             `toml:"registries"`
+	Harnesses    []string               `toml:"harnesses"`
+	HarnessModes map[string]InstallMode `toml:"harness_modes"`
 }
 
 type legacyConfigV1 struct {
@@ -87,6 +171,11 @@ func Load() (*Config, string, error) {
 		return nil, "", err
 	}
 
+	loaded, v3Err := loadV3(configPath)
+	if v3Err == nil {
+		return loaded, configPath, nil
+	}
+
 	loadedV2, v2Err := loadV2(configPath)
 	if v2Err == nil {
 		return loadedV2, configPath, nil
@@ -97,7 +186,7 @@ func Load() (*Config, string, error) {
 		return loadedV1, configPath, nil
 	}
 
-	return nil, "", v2Err
+	return nil, "", v3Err
 }
 
 func ConfigPath() (string, error) {
@@ -121,8 +210,34 @@ func CacheRoot() (string, error) {
 	return filepath.Join(home, ".cache"), nil
 }
 
+func StateRoot() (string, error) {
+	if xdg := strings.TrimSpace(os.Getenv("XDG_STATE_HOME")); xdg != "" {
+		return ExpandPath(xdg)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".local", "state"), nil
+}
+
+func DataRoot() (string, error) {
+	if xdg := strings.TrimSpace(os.Getenv("XDG_DATA_HOME")); xdg != "" {
+		return ExpandPath(xdg)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".local", "share"), nil
+}
+
 func RegistryCachePath(registry Registry) (string, error) {
-	normalized, err := normalizeRegistry(registry)
+	normalized, err := NormalizeRegistry(registry)
 	if err != nil {
 		return "", err
 	}
@@ -135,6 +250,45 @@ func RegistryCachePath(registry Registry) (string, error) {
 	return filepath.Join(root, AppName, "registries", normalized.ID, "repo"), nil
 }
 
+func RegistryScanRoot(registry Registry) (string, error) {
+	if !registry.IsRemote() {
+		return registry.Source, nil
+	}
+
+	root, err := RegistryCachePath(registry)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(registry.Subdir) != "" {
+		root = filepath.Join(root, registry.Subdir)
+	}
+	return root, nil
+}
+
+// LinkManaged reports whether a symlink target lies inside a registry or the
+// registry cache. Only such links are skiller's own symlink installs.
+func LinkManaged(registries []Registry, target string) bool {
+	roots := make([]string, 0, len(registries)+1)
+	if cache, err := CacheRoot(); err == nil {
+		roots = append(roots, filepath.Join(cache, AppName, "registries"))
+	}
+	for _, registry := range registries {
+		if root, err := RegistryScanRoot(registry); err == nil {
+			roots = append(roots, root)
+		}
+	}
+
+	clean := filepath.Clean(target)
+	for _, root := range roots {
+		rel, err := filepath.Rel(filepath.Clean(root), clean)
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
 func configRoot() (string, error) {
 	if xdg := strings.TrimSpace(os.Getenv("XDG_CONFIG_HOME")); xdg != "" {
 		return ExpandPath(xdg)
@@ -150,7 +304,16 @@ func configRoot() (string, error) {
 
 func (c *Config) Save(path string) error {
 	c.Registries = dedupeRegistries(normalizeRegistries(c.Registries))
-	c.Harnesses = dedupePaths(c.Harnesses)
+	definitions, err := normalizeDefinitions(c.HarnessDefinitions)
+	if err != nil {
+		return err
+	}
+	c.HarnessDefinitions = definitions
+	harnesses, err := c.normalizeHarnesses(c.Harnesses)
+	if err != nil {
+		return err
+	}
+	c.Harnesses = harnesses
 
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
@@ -172,9 +335,19 @@ func (c *Config) AddRegistry(input string) error {
 		return errors.New("path is empty")
 	}
 
+	if !isDir(trimmed) {
+		if IsArchiveSource(trimmed) {
+			return c.addFileRegistry(RegistryTypeArchive, trimmed)
+		}
+		if IsIndexSource(trimmed) {
+			return c.addFileRegistry(RegistryTypeIndex, trimmed)
+		}
+	}
+
 	sourceCandidate, ref := splitGitRef(trimmed)
 	if IsGitSource(sourceCandidate) {
-		return c.AddGitRegistry(sourceCandidate, ref)
+		ref, subdir := splitRefSubdir(ref)
+		return c.AddGitRegistry(sourceCandidate, ref, subdir)
 	}
 
 	return c.AddLocalRegistry(trimmed)
@@ -186,7 +359,7 @@ func (c *Config) AddLocalRegistry(path string) error {
 		return err
 	}
 
-	registry, err := normalizeRegistry(Registry{
+	registry, err := NormalizeRegistry(Registry{
 		Type:   RegistryTypeLocal,
 		Source: expanded,
 	})
@@ -198,16 +371,30 @@ func (c *Config) AddLocalRegistry(path string) error {
 	return nil
 }
 
-func (c *Config) AddGitRegistry(source, ref string) error {
+func (c *Config) AddGitRegistry(source, ref, subdir string) error {
 	trimmedSource := strings.TrimSpace(source)
 	if !IsGitSource(trimmedSource) {
 		return errors.New("invalid git registry source")
 	}
 
-	registry, err := normalizeRegistry(Registry{
+	registry, err := NormalizeRegistry(Registry{
 		Type:   RegistryTypeGit,
 		Source: trimmedSource,
 		Ref:    strings.TrimSpace(ref),
+		Subdir: subdir,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Registries = appendUniqueRegistry(c.Registries, registry)
+	return nil
+}
+
+func (c *Config) addFileRegistry(registryType RegistryType, source string) error {
+	registry, err := NormalizeRegistry(Registry{
+		Type:   registryType,
+		Source: source,
 	})
 	if err != nil {
 		return err
@@ -234,47 +421,6 @@ func (c *Config) RemoveRegistry(identifier string) {
 	c.Registries = out
 }
 
-func (c *Config) AddHarness(path string) error {
-	normalized, err := ExpandPath(path)
-	if err != nil {
-		return err
-	}
-	c.Harnesses = appendUniquePath(c.Harnesses, normalized)
-	return nil
-}
-
-func (c *Config) RemoveHarness(path string) {
-	c.Harnesses = removePath(c.Harnesses, path)
-}
-
-func (c *Config) IsCustomHarness(path string) bool {
-	for _, harness := range c.Harnesses {
-		if harness == path {
-			return true
-		}
-	}
-	return false
-}
-
-func DetectKnownHarnesses() []string {
-	var found []string
-	for _, candidate := range knownHarnessCandidates {
-		expanded, err := ExpandPath(candidate)
-		if err != nil {
-			continue
-		}
-
-		info, err := os.Stat(expanded)
-		if err != nil {
-			continue
-		}
-		if info.IsDir() {
-			found = append(found, filepath.Clean(expanded))
-		}
-	}
-	return dedupePaths(found)
-}
-
 func MergeUnique(groups ...[]string) []string {
 	seen := map[string]struct{}{}
 	out := make([]string, 0)
@@ -336,6 +482,10 @@ func IsGitSource(source string) bool {
 		return false
 	}
 
+	if parsed.Scheme == "file" {
+		return parsed.Path != ""
+	}
+
 	if parsed.Host == "" {
 		return false
 	}
@@ -348,16 +498,106 @@ func IsGitSource(source string) bool {
 	}
 }
 
+func IsArchiveSource(source string) bool {
+	return hasFileSource(source, archiveExtensions)
+}
+
+func IsIndexSource(source string) bool {
+	return hasFileSource(source, indexExtensions)
+}
+
+func hasFileSource(source string, extensions []string) bool {
+	if !isFileSource(source) {
+		return false
+	}
+
+	name := strings.ToLower(sourcePath(source))
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func isFileSource(source string) bool {
+	trimmed := strings.TrimSpace(source)
+	if trimmed == "" {
+		return false
+	}
+	parsed, err := url.Parse(trimmed)
+	if err != nil || len(parsed.Scheme) <= 1 {
+		return !strings.HasPrefix(trimmed, "git@")
+	}
+	switch parsed.Scheme {
+	case "file", "http", "https":
+		return parsed.Path != "" || parsed.Host != ""
+	default:
+		return false
+	}
+}
+
+func sourcePath(source string) string {
+	trimmed := strings.TrimSpace(source)
+	parsed, err := url.Parse(trimmed)
+	if err != nil || len(parsed.Scheme) <= 1 {
+		return trimmed
+	}
+	return parsed.Path
+}
+
+func isDir(path string) bool {
+	expanded, err := ExpandPath(path)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(expanded)
+	return err == nil && info.IsDir()
+}
+
+func loadV3(configPath string) (*Config, error) {
+	decoded := &configV3{}
+	meta, err := toml.DecodeFile(configPath, decoded)
+	if err != nil {
+		return nil, err
+	}
+	if meta.IsDefined("harness_modes") {
+		return nil, errLegacyHarnesses
+	}
+
+	definitions, err := normalizeDefinitions(decoded.HarnessDefinitions)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{
+		Offline:            decoded.Offline,
+		Registries:         dedupeRegistries(normalizeRegistries(decoded.Registries)),
+		HarnessDefinitions: definitions,
+	}
+
+	harnesses := make([]Harness, 0, len(decoded.Harnesses))
+	for _, table := range decoded.Harnesses {
+		harnesses = append(harnesses, table.harness())
+	}
+	if cfg.Harnesses, err = cfg.normalizeHarnesses(harnesses); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func loadV2(configPath string) (*Config, error) {
 	decoded := &configV2{}
 	if _, err := toml.DecodeFile(configPath, decoded); err != nil {
 		return nil, err
 	}
 
-	return &Config{
-		Registries: dedupeRegistries(normalizeRegistries(decoded.Registries)),
-		Harnesses:  normalizePaths(decoded.Harnesses),
-	}, nil
+	cfg := &Config{Offline: decoded.Offline, Registries: dedupeRegistries(normalizeRegistries(decoded.Registries))}
+	harnesses, err := cfg.migrateHarnesses(decoded.Harnesses, decoded.HarnessModes)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Harnesses = harnesses
+	return cfg, nil
 }
 
 func loadLegacyV1(configPath string) (*Config, error) {
@@ -366,9 +606,12 @@ func loadLegacyV1(configPath string) (*Config, error) {
 		return nil, err
 	}
 
-	cfg := &Config{
-		Harnesses: normalizePaths(decoded.Harnesses),
+	cfg := &Config{}
+	harnesses, err := cfg.migrateHarnesses(decoded.Harnesses, nil)
+	if err != nil {
+		return nil, err
 	}
+	cfg.Harnesses = harnesses
 
 	for _, registryPath := range decoded.Registries {
 		if err := cfg.AddLocalRegistry(registryPath); err != nil {
@@ -389,10 +632,19 @@ func splitGitRef(input string) (string, string) {
 	return strings.TrimSpace(input[:idx]), strings.TrimSpace(input[idx+1:])
 }
 
+func splitRefSubdir(ref string) (string, string) {
+	idx := strings.Index(ref, ":")
+	if idx < 0 {
+		return ref, ""
+	}
+
+	return strings.TrimSpace(ref[:idx]), strings.TrimSpace(ref[idx+1:])
+}
+
 func normalizeRegistries(registries []Registry) []Registry {
 	out := make([]Registry, 0, len(registries))
 	for _, registry := range registries {
-		normalized, err := normalizeRegistry(registry)
+		normalized, err := NormalizeRegistry(registry)
 		if err != nil {
 			continue
 		}
@@ -402,17 +654,25 @@ func normalizeRegistries(registries []Registry) []Registry {
 	return out
 }
 
-func normalizeRegistry(registry Registry) (Registry, error) {
+func NormalizeRegistry(registry Registry) (Registry, error) {
 	normalized := registry
 	normalized.Name = strings.TrimSpace(normalized.Name)
 	normalized.Source = strings.TrimSpace(normalized.Source)
 	normalized.Ref = strings.TrimSpace(normalized.Ref)
 	normalized.Subdir = strings.Trim(strings.TrimSpace(normalized.Subdir), "/")
+	normalized.SyncInterval = strings.TrimSpace(normalized.SyncInterval)
 
 	if normalized.Type == "" {
-		if IsGitSource(normalized.Source) {
+		switch {
+		case isDir(normalized.Source):
+			normalized.Type = RegistryTypeLocal
+		case IsArchiveSource(normalized.Source):
+			normalized.Type = RegistryTypeArchive
+		case IsIndexSource(normalized.Source):
+			normalized.Type = RegistryTypeIndex
+		case IsGitSource(normalized.Source):
 			normalized.Type = RegistryTypeGit
-		} else {
+		default:
 			normalized.Type = RegistryTypeLocal
 		}
 	}
@@ -433,15 +693,30 @@ func normalizeRegistry(registry Registry) (Registry, error) {
 		if !IsGitSource(normalized.Source) {
 			return Registry{}, errors.New("invalid git registry source")
 		}
+	case RegistryTypeArchive, RegistryTypeIndex:
+		if !isFileSource(normalized.Source) {
+			return Registry{}, fmt.Errorf("invalid %s registry source", normalized.Type)
+		}
+		if parsed, err := url.Parse(normalized.Source); err != nil || len(parsed.Scheme) <= 1 {
+			expanded, err := ExpandPath(normalized.Source)
+			if err != nil {
+				return Registry{}, err
+			}
+			normalized.Source = expanded
+		}
+		normalized.Ref = ""
 	default:
 		return Registry{}, errors.New("unsupported registry type")
 	}
 
 	if normalized.Subdir != "" {
-		normalized.Subdir = filepath.Clean(normalized.Subdir)
+		normalized.Subdir = filepath.ToSlash(filepath.Clean(normalized.Subdir))
 		if normalized.Subdir == "." {
 			normalized.Subdir = ""
 		}
+		if normalized.Subdir == ".." || strings.HasPrefix(normalized.Subdir, "../") {
+			return Registry{}, fmt.Errorf("registry subdir %q is outside the registry", registry.Subdir)
+		}
 	}
 
 	normalized.ID = strings.TrimSpace(normalized.ID)
@@ -480,7 +755,7 @@ func dedupeRegistries(registries []Registry) []Registry {
 	out := make([]Registry, 0, len(registries))
 
 	for _, registry := range registries {
-		normalized, err := normalizeRegistry(registry)
+		normalized, err := NormalizeRegistry(registry)
 		if err != nil {
 			continue
 		}
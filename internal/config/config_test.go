@@ -63,11 +63,135 @@ func TestConfigSaveAndLoadV2(t *testing.T) {
 		t.Fatalf("expected a git registry in loaded config: %#v", loaded.Registries)
 	}
 
-	if len(loaded.Harnesses) != 1 || loaded.Harnesses[0] != filepath.Clean("/tmp/harness-a") {
+	if len(loaded.Harnesses) != 1 || loaded.Harnesses[0].Path != filepath.Clean("/tmp/harness-a") {
 		t.Fatalf("unexpected loaded harnesses: %#v", loaded.Harnesses)
 	}
 }
 
+func TestHarnessModesRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	cfg := &Config{}
+	if err := cfg.AddHarness("/tmp/harness-a"); err != nil {
+		t.Fatalf("add harness failed: %v", err)
+	}
+	if err := cfg.SetHarnessMode("/tmp/harness-a", InstallModeSymlink); err != nil {
+		t.Fatalf("set mode failed: %v", err)
+	}
+	if err := cfg.SetHarnessMode("/tmp/harness-a", "junction"); err == nil {
+		t.Fatalf("expected unknown mode to be rejected")
+	}
+
+	path, err := ConfigPath()
+	if err != nil {
+		t.Fatalf("config path failed: %v", err)
+	}
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	loaded, _, err := Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if mode := loaded.HarnessMode("/tmp/harness-a"); mode != InstallModeSymlink {
+		t.Fatalf("expected symlink mode, got %q", mode)
+	}
+	if mode := loaded.HarnessMode("/tmp/harness-b"); mode != InstallModeCopy {
+		t.Fatalf("expected copy mode for unconfigured harness, got %q", mode)
+	}
+
+	loaded.RemoveHarness("/tmp/harness-a")
+	if mode := loaded.HarnessMode("/tmp/harness-a"); mode != InstallModeCopy {
+		t.Fatalf("expected mode to be dropped with the harness, got %q", mode)
+	}
+}
+
+func TestHarnessProfilesRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	cfg := &Config{}
+	err := cfg.UpdateHarness("/tmp/harness-a", func(harness *Harness) error {
+		harness.Name = "work"
+		harness.Enabled = false
+		harness.Conflict = ConflictPolicyRename
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("update harness failed: %v", err)
+	}
+	if err := cfg.UpdateHarness("/tmp/harness-a", func(harness *Harness) error {
+		harness.Conflict = "merge"
+		return nil
+	}); err == nil {
+		t.Fatalf("expected unknown conflict policy to be rejected")
+	}
+
+	path, err := ConfigPath()
+	if err != nil {
+		t.Fatalf("config path failed: %v", err)
+	}
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	loaded, _, err := Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	harness, ok := loaded.FindHarness("work")
+	if !ok {
+		t.Fatalf("expected harness to be found by name: %#v", loaded.Harnesses)
+	}
+	if harness.Enabled || harness.Kind != HarnessKindCustom || harness.Conflict != ConflictPolicyRename {
+		t.Fatalf("unexpected harness profile: %#v", harness)
+	}
+	for _, active := range loaded.ActiveHarnesses() {
+		if active == harness.Path {
+			t.Fatalf("expected disabled harness to be inactive")
+		}
+	}
+}
+
+func TestLoadV2ConfigMigratesHarnessList(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	configPath, err := ConfigPath()
+	if err != nil {
+		t.Fatalf("config path failed: %v", err)
+	}
+
+	v2 := `harnesses = ["/tmp/harness-a", "/tmp/work/.claude/skills"]
+
+[harness_modes]
+"/tmp/harness-a" = "symlink"
+"/tmp/harness-b" = "hardlink"
+`
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte(v2), 0o644); err != nil {
+		t.Fatalf("write v2 config failed: %v", err)
+	}
+
+	loaded, _, err := Load()
+	if err != nil {
+		t.Fatalf("v2 load failed: %v", err)
+	}
+	if len(loaded.Harnesses) != 3 {
+		t.Fatalf("expected three migrated harnesses, got %#v", loaded.Harnesses)
+	}
+	if mode := loaded.HarnessMode("/tmp/harness-a"); mode != InstallModeSymlink {
+		t.Fatalf("expected migrated symlink mode, got %q", mode)
+	}
+	if mode := loaded.HarnessMode("/tmp/harness-b"); mode != InstallModeHardlink {
+		t.Fatalf("expected migrated hardlink mode, got %q", mode)
+	}
+	if harness, _ := loaded.Harness("/tmp/work/.claude/skills"); harness.Kind != HarnessKindClaude || !harness.Enabled {
+		t.Fatalf("unexpected migrated profile: %#v", harness)
+	}
+}
+
 func TestLoadLegacyConfigMigratesLocalRegistries(t *testing.T) {
 	tempConfigRoot := t.TempDir()
 	t.Setenv("XDG_CONFIG_HOME", tempConfigRoot)
@@ -130,6 +254,7 @@ func TestIsGitSource(t *testing.T) {
 		"https://github.com/acme/skills.git",
 		"git@github.com:acme/skills.git",
 		"ssh://git@github.com/acme/skills.git",
+		"file:///srv/git/skills.git",
 	}
 
 	for _, candidate := range valid {
@@ -142,3 +267,176 @@ func TestIsGitSource(t *testing.T) {
 		t.Fatalf("expected local path to not be treated as git source")
 	}
 }
+
+func TestAddRegistryDetectsArchiveAndIndexSources(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := &Config{}
+
+	cases := map[string]RegistryType{
+		"https://example.com/releases/skills.tar.gz": RegistryTypeArchive,
+		"file:///srv/skills.zip":                     RegistryTypeArchive,
+		"~/downloads/skills.tgz":                     RegistryTypeArchive,
+		"https://example.com/catalog.json?v=2":       RegistryTypeIndex,
+		"/srv/index.toml":                            RegistryTypeIndex,
+		"https://github.com/acme/skills.git#main":    RegistryTypeGit,
+	}
+	for source, want := range cases {
+		before := len(cfg.Registries)
+		if err := cfg.AddRegistry(source); err != nil {
+			t.Fatalf("add %s failed: %v", source, err)
+		}
+		if len(cfg.Registries) != before+1 {
+			t.Fatalf("expected %s to be added", source)
+		}
+		added := cfg.Registries[len(cfg.Registries)-1]
+		if added.Type != want || !added.IsRemote() {
+			t.Fatalf("%s: expected a remote %s registry, got %+v", source, want, added)
+		}
+	}
+
+	for _, registry := range cfg.Registries {
+		if registry.Source == "~/downloads/skills.tgz" {
+			t.Fatalf("expected local archive path to be expanded, got %s", registry.Source)
+		}
+	}
+}
+
+func TestAddGitRegistryWithSubdir(t *testing.T) {
+	cfg := &Config{}
+
+	if err := cfg.AddRegistry("https://github.com/acme/mono.git#:tools/skills/"); err != nil {
+		t.Fatalf("add registry failed: %v", err)
+	}
+	registry := cfg.Registries[0]
+	if registry.Source != "https://github.com/acme/mono.git" || registry.Ref != "" || registry.Subdir != "tools/skills" {
+		t.Fatalf("expected default ref with subdir, got %#v", registry)
+	}
+
+	if err := cfg.AddRegistry("https://github.com/acme/mono.git#v2:../outside"); err == nil {
+		t.Fatalf("expected a subdir outside the repository to be rejected")
+	}
+}
+
+func TestDetectProjectHarnessesWalksUpToGitRoot(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	outer := t.TempDir()
+	root := filepath.Join(outer, "repo")
+	nested := filepath.Join(root, "pkg", "sub")
+	for _, dir := range []string{
+		filepath.Join(outer, ".claude", "skills"),
+		filepath.Join(root, ".git"),
+		filepath.Join(root, ".claude", "skills"),
+		filepath.Join(root, "pkg", ".agents", "skills"),
+		nested,
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+	}
+
+	found := (&Config{}).DetectProjectHarnesses(nested)
+	want := []string{filepath.Join(root, "pkg", ".agents", "skills"), filepath.Join(root, ".claude", "skills")}
+	if strings.Join(found, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, found)
+	}
+
+	if got, ok := ProjectRoot(nested); !ok || got != root {
+		t.Fatalf("expected project root %s, got %s", root, got)
+	}
+}
+
+func TestHarnessDefinitionsFromConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+
+	tools := filepath.Join(t.TempDir(), "tools")
+	t.Setenv("ACME_HOME", tools)
+	for _, dir := range []string{filepath.Join(tools, "skills"), filepath.Join(home, ".claude", "skills")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+	}
+
+	configPath, err := ConfigPath()
+	if err != nil {
+		t.Fatalf("config path failed: %v", err)
+	}
+	data := `[[harness_definitions]]
+name = "acme"
+paths = ["$ACME_HOME/skills", "$ACME_MISSING/skills"]
+project = ".acme/skills"
+`
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte(data), 0o644); err != nil {
+		t.Fatalf("write config failed: %v", err)
+	}
+
+	loaded, _, err := Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	detected := loaded.DetectKnownHarnesses()
+	want := []string{filepath.Join(home, ".claude", "skills"), filepath.Join(tools, "skills")}
+	if strings.Join(detected, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, detected)
+	}
+	if kind := loaded.KindForPath(filepath.Join(tools, "skills")); kind != "acme" {
+		t.Fatalf("expected acme kind, got %q", kind)
+	}
+	if kind := loaded.KindForPath("/work/repo/.acme/skills"); kind != "acme" {
+		t.Fatalf("expected project path to match acme, got %q", kind)
+	}
+
+	if err := loaded.UpdateHarness("/tmp/harness-a", func(harness *Harness) error {
+		harness.Kind = "unknown-agent"
+		return nil
+	}); err == nil {
+		t.Fatalf("expected an unknown kind to be rejected")
+	}
+}
+
+func TestLoadKeepsHarnessesOfRemovedDefinitions(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	configPath, err := ConfigPath()
+	if err != nil {
+		t.Fatalf("config path failed: %v", err)
+	}
+	data := `[[harnesses]]
+path = "/tmp/acme/skills"
+kind = "acme"
+install_mode = "symlink"
+`
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte(data), 0o644); err != nil {
+		t.Fatalf("write config failed: %v", err)
+	}
+
+	loaded, _, err := Load()
+	if err != nil {
+		t.Fatalf("expected load to keep going, got %v", err)
+	}
+	harness, ok := loaded.Harness("/tmp/acme/skills")
+	if !ok || harness.Kind != "acme" || !strings.Contains(harness.Problem, "unknown harness kind") {
+		t.Fatalf("expected the harness to be reported as invalid, got %#v", harness)
+	}
+	if loaded.HarnessMode("/tmp/acme/skills") != InstallModeSymlink {
+		t.Fatalf("expected the rest of the profile to load")
+	}
+
+	if err := loaded.Save(configPath); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	saved, err := os.ReadFile(configPath)
+	if err != nil || !strings.Contains(string(saved), `kind = "acme"`) {
+		t.Fatalf("expected the kind to be kept for the user to fix, got %s (%v)", saved, err)
+	}
+}
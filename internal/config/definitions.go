@@ -0,0 +1,163 @@
+package config
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"skiller/internal/adapter"
+
+	"github.com/BurntSushi/toml"
+)
+
+//go:embed harnesses.toml
+var defaultDefinitionsTOML string
+
+type HarnessDefinition struct {
+	Name    string   `toml:"name"`
+	Paths   []string `toml:"paths"`
+	Project string   `toml:"project,omitempty"`
+	Format  string   `toml:"format,omitempty"`
+}
+
+var defaultDefinitions = mustDecodeDefinitions(defaultDefinitionsTOML)
+
+func mustDecodeDefinitions(data string) []HarnessDefinition {
+	var decoded struct {
+		Definitions []HarnessDefinition `toml:"definitions"`
+	}
+	if _, err := toml.Decode(data, &decoded); err != nil {
+		panic(fmt.Sprintf("embedded harness definitions: %v", err))
+	}
+	definitions, err := normalizeDefinitions(decoded.Definitions)
+	if err != nil {
+		panic(fmt.Sprintf("embedded harness definitions: %v", err))
+	}
+	return definitions
+}
+
+func DefaultHarnessDefinitions() []HarnessDefinition {
+	return append([]HarnessDefinition(nil), defaultDefinitions...)
+}
+
+func NormalizeHarnessDefinition(definition HarnessDefinition) (HarnessDefinition, error) {
+	normalized := definition
+	normalized.Name = strings.ToLower(strings.TrimSpace(definition.Name))
+	if normalized.Name == "" {
+		return HarnessDefinition{}, errors.New("harness definition name is required")
+	}
+	if normalized.Name == string(HarnessKindCustom) || strings.ContainsAny(normalized.Name, " \t/") {
+		return HarnessDefinition{}, fmt.Errorf("invalid harness definition name %q", definition.Name)
+	}
+
+	normalized.Paths = nil
+	for _, path := range definition.Paths {
+		if trimmed := strings.TrimSpace(path); trimmed != "" {
+			normalized.Paths = append(normalized.Paths, trimmed)
+		}
+	}
+
+	format, err := adapter.ParseFormat(definition.Format)
+	if err != nil {
+		return HarnessDefinition{}, fmt.Errorf("harness definition %s: %w", normalized.Name, err)
+	}
+	normalized.Format = string(format)
+	if format == adapter.FormatSkill {
+		normalized.Format = ""
+	}
+
+	if project := strings.TrimSpace(definition.Project); project != "" {
+		clean := filepath.Clean(filepath.FromSlash(project))
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return HarnessDefinition{}, fmt.Errorf("harness definition %s: project path must be relative to the project", normalized.Name)
+		}
+		normalized.Project = clean
+	}
+	return normalized, nil
+}
+
+func normalizeDefinitions(definitions []HarnessDefinition) ([]HarnessDefinition, error) {
+	out := make([]HarnessDefinition, 0, len(definitions))
+	for _, definition := range definitions {
+		normalized, err := NormalizeHarnessDefinition(definition)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, normalized)
+	}
+	return out, nil
+}
+
+func (c *Config) Definitions() []HarnessDefinition {
+	definitions := DefaultHarnessDefinitions()
+	for _, custom := range c.HarnessDefinitions {
+		replaced := false
+		for i := range definitions {
+			if definitions[i].Name == custom.Name {
+				definitions[i] = custom
+				replaced = true
+			}
+		}
+		if !replaced {
+			definitions = append(definitions, custom)
+		}
+	}
+	return definitions
+}
+
+func (c *Config) definition(name string) (HarnessDefinition, bool) {
+	for _, definition := range c.Definitions() {
+		if definition.Name == name {
+			return definition, true
+		}
+	}
+	return HarnessDefinition{}, false
+}
+
+func (d HarnessDefinition) CandidatePaths() []string {
+	var out []string
+	for _, path := range d.Paths {
+		expanded, err := expandDefinitionPath(path)
+		if err != nil {
+			continue
+		}
+		out = append(out, expanded)
+	}
+	return dedupePaths(out)
+}
+
+func expandDefinitionPath(path string) (string, error) {
+	var missing string
+	expanded := os.Expand(path, func(name string) string {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			return value
+		}
+
+		var root string
+		var err error
+		switch name {
+		case "XDG_CONFIG_HOME":
+			root, err = configRoot()
+		case "XDG_CACHE_HOME":
+			root, err = CacheRoot()
+		case "XDG_STATE_HOME":
+			root, err = StateRoot()
+		case "XDG_DATA_HOME":
+			root, err = DataRoot()
+		default:
+			missing = name
+			return ""
+		}
+		if err != nil {
+			missing = name
+		}
+		return root
+	})
+	if missing != "" {
+		return "", fmt.Errorf("%s is not set", missing)
+	}
+	return ExpandPath(expanded)
+}
@@ -0,0 +1,393 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"skiller/internal/adapter"
+)
+
+type HarnessKind string
+
+const (
+	HarnessKindClaude   HarnessKind = "claude"
+	HarnessKindOpenCode HarnessKind = "opencode"
+	HarnessKindAgents   HarnessKind = "agents"
+	HarnessKindCustom   HarnessKind = "custom"
+)
+
+type ConflictPolicy string
+
+const (
+	ConflictPolicyAsk       ConflictPolicy = ""
+	ConflictPolicySkip      ConflictPolicy = "skip"
+	ConflictPolicyOverwrite ConflictPolicy = "overwrite"
+	ConflictPolicyRename    ConflictPolicy = "rename"
+)
+
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case ConflictPolicyAsk, ConflictPolicySkip, ConflictPolicyOverwrite, ConflictPolicyRename:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown conflict policy %q (use skip, overwrite or rename)", value)
+	}
+}
+
+var errUnknownHarnessKind = errors.New("unknown harness kind")
+
+func (c *Config) parseHarnessKind(value string) (HarnessKind, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(value))
+	if trimmed == string(HarnessKindCustom) {
+		return HarnessKindCustom, nil
+	}
+
+	names := make([]string, 0)
+	for _, definition := range c.Definitions() {
+		if definition.Name == trimmed {
+			return HarnessKind(trimmed), nil
+		}
+		names = append(names, definition.Name)
+	}
+	return "", fmt.Errorf("%w %q (use %s or custom)", errUnknownHarnessKind, value, strings.Join(names, ", "))
+}
+
+type Harness struct {
+	Name        string         `toml:"name,omitempty"`
+	Path        string         `toml:"path"`
+	Kind        HarnessKind    `toml:"kind"`
+	Enabled     bool           `toml:"enabled"`
+	InstallMode InstallMode    `toml:"install_mode,omitempty"`
+	Conflict    ConflictPolicy `toml:"conflict,omitempty"`
+	Problem     string         `toml:"-"`
+}
+
+type harnessTable struct {
+	Name        string         `toml:"name"`
+	Path        string         `toml:"path"`
+	Kind        HarnessKind    `toml:"kind"`
+	Enabled     *bool          `toml:"enabled"`
+	InstallMode InstallMode    `toml:"install_mode"`
+	Conflict    ConflictPolicy `toml:"conflict"`
+}
+
+func (t harnessTable) harness() Harness {
+	harness := Harness{
+		Name:        t.Name,
+		Path:        t.Path,
+		Kind:        t.Kind,
+		Enabled:     true,
+		InstallMode: t.InstallMode,
+		Conflict:    t.Conflict,
+	}
+	if t.Enabled != nil {
+		harness.Enabled = *t.Enabled
+	}
+	return harness
+}
+
+func (h Harness) DisplayName() string {
+	if strings.TrimSpace(h.Name) != "" {
+		return strings.TrimSpace(h.Name)
+	}
+	return h.Path
+}
+
+func (c *Config) normalizeHarness(harness Harness) (Harness, error) {
+	normalized := harness
+	normalized.Name = strings.TrimSpace(normalized.Name)
+
+	path, err := ExpandPath(normalized.Path)
+	if err != nil {
+		return Harness{}, fmt.Errorf("harness path: %w", err)
+	}
+	normalized.Path = path
+
+	var kindErr error
+	if strings.TrimSpace(string(normalized.Kind)) == "" {
+		normalized.Kind = c.KindForPath(path)
+	} else if kind, err := c.parseHarnessKind(string(normalized.Kind)); err != nil {
+		normalized.Kind = HarnessKind(strings.ToLower(strings.TrimSpace(string(normalized.Kind))))
+		kindErr = fmt.Errorf("harness %s: %w", path, err)
+	} else {
+		normalized.Kind = kind
+	}
+
+	mode, err := ParseInstallMode(string(normalized.InstallMode))
+	if err != nil {
+		return Harness{}, fmt.Errorf("harness %s: %w", path, err)
+	}
+	normalized.InstallMode = mode
+	if mode == InstallModeCopy {
+		normalized.InstallMode = ""
+	}
+
+	if normalized.Conflict, err = ParseConflictPolicy(string(normalized.Conflict)); err != nil {
+		return Harness{}, fmt.Errorf("harness %s: %w", path, err)
+	}
+
+	normalized.Problem = ""
+	if kindErr != nil {
+		normalized.Problem = kindErr.Error()
+		return normalized, kindErr
+	}
+	return normalized, nil
+}
+
+func (c *Config) KindForPath(path string) HarnessKind {
+	if definition, ok := c.definitionForPath(path); ok {
+		return HarnessKind(definition.Name)
+	}
+	return HarnessKindCustom
+}
+
+func (c *Config) definitionForPath(path string) (HarnessDefinition, bool) {
+	clean := filepath.Clean(path)
+	definitions := c.Definitions()
+	for _, definition := range definitions {
+		for _, candidate := range definition.CandidatePaths() {
+			if candidate == clean {
+				return definition, true
+			}
+		}
+	}
+	for _, definition := range definitions {
+		if definition.Project != "" && strings.HasSuffix(clean, string(filepath.Separator)+definition.Project) {
+			return definition, true
+		}
+	}
+	return HarnessDefinition{}, false
+}
+
+func (c *Config) HarnessFormat(path string) adapter.Format {
+	definition, ok := c.definitionForPath(path)
+	if harness, configured := c.Harness(path); configured {
+		definition, ok = c.definition(string(harness.Kind))
+	}
+	if !ok || definition.Format == "" {
+		return adapter.FormatSkill
+	}
+	return adapter.Format(definition.Format)
+}
+
+func (c *Config) harnessIndex(path string) int {
+	clean := filepath.Clean(path)
+	for i, harness := range c.Harnesses {
+		if filepath.Clean(harness.Path) == clean {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Config) Harness(path string) (Harness, bool) {
+	if i := c.harnessIndex(path); i >= 0 {
+		return c.Harnesses[i], true
+	}
+	return Harness{}, false
+}
+
+func (c *Config) FindHarness(identifier string) (Harness, bool) {
+	trimmed := strings.TrimSpace(identifier)
+	for _, harness := range c.Harnesses {
+		if harness.Name != "" && harness.Name == trimmed {
+			return harness, true
+		}
+	}
+	if expanded, err := ExpandPath(trimmed); err == nil {
+		return c.Harness(expanded)
+	}
+	return Harness{}, false
+}
+
+func (c *Config) AddHarness(path string) error {
+	expanded, err := ExpandPath(path)
+	if err != nil {
+		return err
+	}
+	if c.harnessIndex(expanded) >= 0 {
+		return nil
+	}
+
+	harness, err := c.normalizeHarness(Harness{Path: expanded, Enabled: true})
+	if err != nil {
+		return err
+	}
+	c.Harnesses = append(c.Harnesses, harness)
+	return nil
+}
+
+func (c *Config) UpdateHarness(path string, update func(*Harness) error) error {
+	expanded, err := ExpandPath(path)
+	if err != nil {
+		return err
+	}
+
+	i := c.harnessIndex(expanded)
+	harness := Harness{Path: expanded, Enabled: true}
+	if i >= 0 {
+		harness = c.Harnesses[i]
+	}
+	if err := update(&harness); err != nil {
+		return err
+	}
+
+	normalized, err := c.normalizeHarness(harness)
+	if err != nil {
+		return err
+	}
+	if i >= 0 {
+		c.Harnesses[i] = normalized
+	} else {
+		c.Harnesses = append(c.Harnesses, normalized)
+	}
+	return nil
+}
+
+func (c *Config) RemoveHarness(path string) {
+	i := c.harnessIndex(path)
+	if i < 0 {
+		return
+	}
+	c.Harnesses = append(c.Harnesses[:i:i], c.Harnesses[i+1:]...)
+}
+
+func (c *Config) HarnessMode(path string) InstallMode {
+	if harness, ok := c.Harness(path); ok && harness.InstallMode != "" {
+		return harness.InstallMode
+	}
+	return InstallModeCopy
+}
+
+func (c *Config) SetHarnessMode(path string, mode InstallMode) error {
+	parsed, err := ParseInstallMode(string(mode))
+	if err != nil {
+		return err
+	}
+	if _, ok := c.Harness(path); !ok && parsed == InstallModeCopy {
+		return nil
+	}
+	return c.UpdateHarness(path, func(harness *Harness) error {
+		harness.InstallMode = parsed
+		return nil
+	})
+}
+
+func (c *Config) HarnessConflict(path string) ConflictPolicy {
+	harness, _ := c.Harness(path)
+	return harness.Conflict
+}
+
+func (c *Config) SetHarnessEnabled(path string, enabled bool) error {
+	return c.UpdateHarness(path, func(harness *Harness) error {
+		harness.Enabled = enabled
+		return nil
+	})
+}
+
+func (c *Config) HarnessEnabled(path string) bool {
+	harness, ok := c.Harness(path)
+	return !ok || harness.Enabled
+}
+
+func (c *Config) IsCustomHarness(path string) bool {
+	return c.harnessIndex(path) >= 0 && !c.isKnownHarnessPath(path)
+}
+
+func (c *Config) isKnownHarnessPath(path string) bool {
+	clean := filepath.Clean(path)
+	for _, definition := range c.Definitions() {
+		for _, candidate := range definition.CandidatePaths() {
+			if candidate == clean {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Config) ActiveHarnesses() []string {
+	var paths []string
+	for _, harness := range c.Harnesses {
+		if harness.Enabled {
+			paths = append(paths, harness.Path)
+		}
+	}
+	for _, detected := range c.DetectKnownHarnesses() {
+		if c.HarnessEnabled(detected) {
+			paths = append(paths, detected)
+		}
+	}
+	return MergeUnique(paths)
+}
+
+func (c *Config) DetectKnownHarnesses() []string {
+	var found []string
+	for _, definition := range c.Definitions() {
+		for _, candidate := range definition.CandidatePaths() {
+			if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+				found = append(found, candidate)
+			}
+		}
+	}
+	return dedupePaths(found)
+}
+
+func (c *Config) normalizeHarnesses(harnesses []Harness) ([]Harness, error) {
+	out := make([]Harness, 0, len(harnesses))
+	seen := map[string]struct{}{}
+	for _, harness := range harnesses {
+		normalized, err := c.normalizeHarness(harness)
+		if err != nil && !errors.Is(err, errUnknownHarnessKind) {
+			return nil, err
+		}
+		if _, ok := seen[normalized.Path]; ok {
+			continue
+		}
+		seen[normalized.Path] = struct{}{}
+		out = append(out, normalized)
+	}
+	return out, nil
+}
+
+func (c *Config) migrateHarnesses(paths []string, modes map[string]InstallMode) ([]Harness, error) {
+	harnesses := make([]Harness, 0, len(paths)+len(modes))
+	for _, path := range normalizePaths(paths) {
+		harnesses = append(harnesses, Harness{Path: path, Enabled: true})
+	}
+	modePaths := make([]string, 0, len(modes))
+	for path := range modes {
+		modePaths = append(modePaths, path)
+	}
+	sort.Strings(modePaths)
+
+	for _, path := range modePaths {
+		mode := modes[path]
+		expanded, err := ExpandPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for i := range harnesses {
+			if harnesses[i].Path == expanded {
+				harnesses[i].InstallMode = mode
+				found = true
+			}
+		}
+		if !found {
+			harnesses = append(harnesses, Harness{Path: expanded, Enabled: true, InstallMode: mode})
+		}
+	}
+
+	if len(harnesses) == 0 {
+		return nil, nil
+	}
+	return c.normalizeHarnesses(harnesses)
+}
+
+var errLegacyHarnesses = errors.New("config uses the harness_modes table")
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+func ProjectRoot(dir string) (string, bool) {
+	current, err := ExpandPath(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current, true
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", false
+		}
+		current = parent
+	}
+}
+
+func (c *Config) DetectProjectHarnesses(dir string) []string {
+	start, err := ExpandPath(dir)
+	if err != nil {
+		return nil
+	}
+	root, ok := ProjectRoot(start)
+	if !ok {
+		root = start
+	}
+
+	definitions := c.Definitions()
+	var found []string
+	for current := start; ; current = filepath.Dir(current) {
+		for _, definition := range definitions {
+			if definition.Project == "" {
+				continue
+			}
+			path := filepath.Join(current, definition.Project)
+			if c.isKnownHarnessPath(path) {
+				continue
+			}
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				found = append(found, path)
+			}
+		}
+		if current == root || filepath.Dir(current) == current {
+			break
+		}
+	}
+	return found
+}
+
+func (c *Config) DefaultProjectHarness(dir string) (string, error) {
+	root, ok := ProjectRoot(dir)
+	if !ok {
+		expanded, err := ExpandPath(dir)
+		if err != nil {
+			return "", err
+		}
+		root = expanded
+	}
+	for _, definition := range c.Definitions() {
+		if definition.Project != "" {
+			return filepath.Join(root, definition.Project), nil
+		}
+	}
+	return "", errors.New("no harness definition has a project path")
+}
@@ -7,9 +7,39 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"syscall"
 )
 
 func CopyDir(src, dst string) error {
+	return copyTree(src, dst, func(path, target string, info fs.FileInfo) error {
+		if err := copyFile(path, target, info.Mode().Perm()); err != nil {
+			return err
+		}
+		return os.Chtimes(target, info.ModTime(), info.ModTime())
+	})
+}
+
+func CopyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return copyFile(src, dst, info.Mode().Perm())
+}
+
+func LinkDir(src, dst string) error {
+	return copyTree(src, dst, func(path, target string, info fs.FileInfo) error {
+		if err := os.Link(path, target); err != nil {
+			if errors.Is(err, syscall.EXDEV) {
+				return fmt.Errorf("hardlink %s: source and destination are on different filesystems: %w", path, err)
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func copyTree(src, dst string, placeFile func(path, target string, info fs.FileInfo) error) error {
 	srcInfo, err := os.Stat(src)
 	if err != nil {
 		return err
@@ -81,11 +111,7 @@ func CopyDir(src, dst string) error {
 			return nil
 		}
 
-		if err := copyFile(path, target, info.Mode().Perm()); err != nil {
-			return err
-		}
-
-		return os.Chtimes(target, info.ModTime(), info.ModTime())
+		return placeFile(path, target, info)
 	})
 }
 
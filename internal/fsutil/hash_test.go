@@ -0,0 +1,44 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashDirIgnoresGroupWritableBits(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "SKILL.md")
+	if err := os.WriteFile(path, []byte("# alpha"), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatalf("chmod failed: %v", err)
+	}
+	before, err := HashDir(root)
+	if err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+
+	if err := os.Chmod(path, 0o664); err != nil {
+		t.Fatalf("chmod failed: %v", err)
+	}
+	after, err := HashDir(root)
+	if err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	if before != after {
+		t.Fatalf("expected a umask difference to keep the hash, got %s and %s", before, after)
+	}
+
+	if err := os.Chmod(path, 0o755); err != nil {
+		t.Fatalf("chmod failed: %v", err)
+	}
+	executable, err := HashDir(root)
+	if err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	if executable == before {
+		t.Fatalf("expected the executable bit to change the hash")
+	}
+}
@@ -0,0 +1,190 @@
+package install
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"skiller/internal/adapter"
+	"skiller/internal/config"
+	"skiller/internal/scan"
+	"skiller/internal/trash"
+)
+
+func installAdapted(skillSourcePath, harnessPath, skillName string, action ConflictAction, opts Options) (InstallResult, error) {
+	if mode, _ := config.ParseInstallMode(string(opts.Mode)); mode != config.InstallModeCopy {
+		return InstallResult{}, fmt.Errorf("%s harnesses only support the copy install mode", opts.Format)
+	}
+
+	installed, err := adaptedNames(harnessPath)
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	format := opts.Format
+	destination := filepath.Join(harnessPath, format.FileName(skillName))
+	result := InstallResult{Name: skillName, Destination: destination}
+
+	replace := false
+	if installed[skillName] || (!format.Shared() && exists(destination)) {
+		result.Conflict = true
+		switch action {
+		case ConflictSkip:
+			return result, nil
+		case ConflictOverwrite:
+			replace = true
+		case ConflictRename:
+			for i := 2; ; i++ {
+				candidate := fmt.Sprintf("%s-%d", skillName, i)
+				path := filepath.Join(harnessPath, format.FileName(candidate))
+				if !installed[candidate] && (format.Shared() || !exists(path)) {
+					result.Name, result.Destination = candidate, path
+					break
+				}
+			}
+			result.Renamed = true
+		default:
+			return InstallResult{}, fmt.Errorf("unknown conflict action: %s", action)
+		}
+	}
+
+	rendered, err := adapter.Render(format, skillSourcePath, result.Name)
+	if err != nil {
+		return InstallResult{}, err
+	}
+
+	var reason trash.Reason
+	if replace {
+		reason = trash.ReasonOverwrite
+	}
+
+	if format.Shared() {
+		doc, err := os.ReadFile(result.Destination)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return InstallResult{}, err
+		}
+		entry, err := writeSection(harnessPath, result.Destination, result.Name, doc, adapter.UpsertSection(doc, result.Name, rendered), reason)
+		if err != nil {
+			return InstallResult{}, err
+		}
+		result.Installed = true
+		result.Trashed = entry
+		return result, nil
+	}
+
+	entry, written, err := writeFile(harnessPath, result.Destination, rendered, reason)
+	result.Installed = written
+	result.Trashed = entry
+	if err != nil && !written {
+		return InstallResult{}, err
+	}
+	return result, err
+}
+
+func uninstallAdapted(harnessPath, skillName string) (trash.Entry, error) {
+	skill, ok, err := scan.FindAdapted(harnessPath, skillName)
+	if err != nil {
+		return trash.Entry{}, err
+	}
+	if !ok {
+		return trash.Entry{}, fmt.Errorf("%s is not installed in %s: %w", skillName, harnessPath, os.ErrNotExist)
+	}
+
+	path := strings.TrimSuffix(skill.Path, "#"+skillName)
+	if path == skill.Path {
+		return trash.Move(path, path, trash.ReasonUninstall)
+	}
+
+	doc, err := os.ReadFile(path)
+	if err != nil {
+		return trash.Entry{}, err
+	}
+	remaining, _ := adapter.RemoveSection(doc, skillName)
+	entry, err := writeSection(harnessPath, path, skillName, doc, remaining, trash.ReasonUninstall)
+	if err != nil {
+		return trash.Entry{}, err
+	}
+	if entry == nil {
+		return trash.Entry{}, fmt.Errorf("%s has no %s section: %w", path, skillName, os.ErrNotExist)
+	}
+	return *entry, nil
+}
+
+func writeSection(harnessPath, path, name string, doc, updated []byte, reason trash.Reason) (*trash.Entry, error) {
+	section, found := adapter.Section(doc, name)
+	if strings.TrimSpace(string(updated)) == "" {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	} else if _, _, err := writeFile(harnessPath, path, updated, ""); err != nil {
+		return nil, err
+	}
+	if !found || reason == "" {
+		return nil, nil
+	}
+
+	entry, err := trash.MoveSection(section, path, name, reason)
+	if err != nil {
+		if _, _, restoreErr := writeFile(harnessPath, path, doc, ""); restoreErr != nil {
+			return nil, fmt.Errorf("%w (and restoring %s failed: %v)", err, path, restoreErr)
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func adaptedNames(harnessPath string) (map[string]bool, error) {
+	if err := os.MkdirAll(harnessPath, 0o755); err != nil {
+		return nil, err
+	}
+	skills, err := scan.ScanHarness(harnessPath)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[string]bool{}
+	for _, skill := range skills {
+		if skill.Adapted {
+			names[skill.Name] = true
+		}
+	}
+	return names, nil
+}
+
+func writeFile(harnessPath, destination string, data []byte, reason trash.Reason) (*trash.Entry, bool, error) {
+	staging, err := os.MkdirTemp(harnessPath, stagingPattern)
+	if err != nil {
+		return nil, false, fmt.Errorf("create staging directory: %w", err)
+	}
+	keepStaging := false
+	defer func() {
+		if !keepStaging {
+			_ = os.RemoveAll(staging)
+		}
+	}()
+
+	staged := filepath.Join(staging, filepath.Base(destination))
+	if err := os.WriteFile(staged, data, 0o644); err != nil {
+		return nil, false, err
+	}
+
+	backup := filepath.Join(staging, "previous")
+	replace := exists(destination)
+	if err := swap(staged, destination, backup, replace); err != nil {
+		var rollbackErr *rollbackError
+		keepStaging = errors.As(err, &rollbackErr)
+		return nil, false, err
+	}
+	if !replace || reason == "" {
+		return nil, true, nil
+	}
+
+	entry, err := trash.Move(backup, destination, reason)
+	if err != nil {
+		keepStaging = true
+		return nil, true, fmt.Errorf("written, but the previous version could not be moved to the trash (kept at %s): %w", backup, err)
+	}
+	return &entry, true, nil
+}
@@ -6,7 +6,18 @@ import (
 	"os"
 	"path/filepath"
 
+	"skiller/internal/adapter"
+	"skiller/internal/config"
 	"skiller/internal/fsutil"
+	"skiller/internal/provenance"
+	"skiller/internal/trash"
+)
+
+const stagingPattern = ".skiller-stage-*"
+
+var (
+	copyDir = fsutil.CopyDir
+	rename  = os.Rename
 )
 
 type ConflictAction string
@@ -23,9 +34,21 @@ type InstallResult struct {
 	Renamed     bool
 	Name        string
 	Destination string
+	Trashed     *trash.Entry
+}
+
+type Options struct {
+	Name       string
+	Mode       config.InstallMode
+	Provenance *provenance.Record
+	Format     adapter.Format
 }
 
 func InstallSkill(skillSourcePath, harnessPath string, action ConflictAction) (InstallResult, error) {
+	return InstallSkillWithOptions(skillSourcePath, harnessPath, action, Options{})
+}
+
+func InstallSkillWithOptions(skillSourcePath, harnessPath string, action ConflictAction, opts Options) (InstallResult, error) {
 	sourceInfo, err := os.Stat(skillSourcePath)
 	if err != nil {
 		return InstallResult{}, err
@@ -34,11 +57,29 @@ func InstallSkill(skillSourcePath, harnessPath string, action ConflictAction) (I
 		return InstallResult{}, errors.New("skill source path is not a directory")
 	}
 
+	mode, err := config.ParseInstallMode(string(opts.Mode))
+	if err != nil {
+		return InstallResult{}, err
+	}
+
 	if err := os.MkdirAll(harnessPath, 0o755); err != nil {
 		return InstallResult{}, err
 	}
 
 	skillName := filepath.Base(skillSourcePath)
+	if opts.Name != "" {
+		if opts.Name != filepath.Base(opts.Name) || opts.Name == "." || opts.Name == ".." {
+			return InstallResult{}, fmt.Errorf("invalid skill folder name: %s", opts.Name)
+		}
+		skillName = opts.Name
+	}
+	if format, err := adapter.ParseFormat(string(opts.Format)); err != nil {
+		return InstallResult{}, err
+	} else if format != adapter.FormatSkill {
+		opts.Format = format
+		return installAdapted(skillSourcePath, harnessPath, skillName, action, opts)
+	}
+
 	destination := filepath.Join(harnessPath, skillName)
 
 	result := InstallResult{
@@ -46,15 +87,14 @@ func InstallSkill(skillSourcePath, harnessPath string, action ConflictAction) (I
 		Destination: destination,
 	}
 
+	replace := false
 	if exists(destination) {
 		result.Conflict = true
 		switch action {
 		case ConflictSkip:
 			return result, nil
 		case ConflictOverwrite:
-			if err := os.RemoveAll(destination); err != nil {
-				return InstallResult{}, err
-			}
+			replace = true
 		case ConflictRename:
 			renamePath, renameName := nextAvailableDestination(harnessPath, skillName)
 			destination = renamePath
@@ -66,34 +106,148 @@ func InstallSkill(skillSourcePath, harnessPath string, action ConflictAction) (I
 		}
 	}
 
-	if err := fsutil.CopyDir(skillSourcePath, destination); err != nil {
+	staging, err := os.MkdirTemp(harnessPath, stagingPattern)
+	if err != nil {
+		return InstallResult{}, fmt.Errorf("create staging directory: %w", err)
+	}
+	keepStaging := false
+	defer func() {
+		if !keepStaging {
+			_ = os.RemoveAll(staging)
+		}
+	}()
+
+	staged := filepath.Join(staging, skillName)
+	if err := place(skillSourcePath, staged, mode); err != nil {
 		return InstallResult{}, err
 	}
 
+	if opts.Provenance != nil && mode != config.InstallModeSymlink {
+		record := *opts.Provenance
+		if mode != config.InstallModeCopy {
+			record.InstallMode = string(mode)
+		}
+		if err := provenance.Write(staged, record); err != nil {
+			return InstallResult{}, err
+		}
+	}
+
+	backup := filepath.Join(staging, "previous")
+	if err := swap(staged, destination, backup, replace); err != nil {
+		var rollbackErr *rollbackError
+		keepStaging = errors.As(err, &rollbackErr)
+		return InstallResult{}, err
+	}
 	result.Installed = true
+
+	// A replaced symlink is only a link and is dropped with the staging dir.
+	if replace {
+		if info, err := os.Lstat(backup); err == nil && info.IsDir() {
+			entry, err := trash.Move(backup, destination, trash.ReasonOverwrite)
+			if err != nil {
+				keepStaging = true
+				return result, fmt.Errorf("installed, but the previous version could not be moved to the trash (kept at %s): %w", backup, err)
+			}
+			result.Trashed = &entry
+		}
+	}
+
 	return result, nil
 }
 
-func UninstallSkill(harnessPath, skillName string) error {
+type rollbackError struct {
+	backup string
+	err    error
+}
+
+func (e *rollbackError) Error() string {
+	return fmt.Sprintf("%v (previous version kept at %s)", e.err, e.backup)
+}
+
+func (e *rollbackError) Unwrap() error { return e.err }
+
+func swap(staged, destination, backup string, replace bool) error {
+	if replace {
+		if err := rename(destination, backup); err != nil {
+			return fmt.Errorf("move aside %s: %w", destination, err)
+		}
+	}
+
+	if err := rename(staged, destination); err != nil {
+		err = fmt.Errorf("move staged install into %s: %w", destination, err)
+		if !replace {
+			return err
+		}
+		if restoreErr := rename(backup, destination); restoreErr != nil {
+			return &rollbackError{backup: backup, err: fmt.Errorf("%w; rollback failed: %v", err, restoreErr)}
+		}
+		return err
+	}
+	return nil
+}
+
+func place(source, destination string, mode config.InstallMode) error {
+	switch mode {
+	case config.InstallModeSymlink:
+		absolute, err := filepath.Abs(source)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(absolute, destination)
+	case config.InstallModeHardlink:
+		if err := fsutil.LinkDir(source, destination); err != nil {
+			return err
+		}
+		if err := os.Remove(filepath.Join(destination, provenance.FileName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	default:
+		return copyDir(source, destination)
+	}
+}
+
+func UninstallSkill(harnessPath, skillName string, registries []config.Registry) (trash.Entry, error) {
 	targetPath := filepath.Join(harnessPath, skillName)
+	linkInfo, err := os.Lstat(targetPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return uninstallAdapted(harnessPath, skillName)
+	}
+	if err != nil {
+		return trash.Entry{}, err
+	}
+	if linkInfo.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(targetPath)
+		if err != nil {
+			return trash.Entry{}, err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(harnessPath, target)
+		}
+		if !config.LinkManaged(registries, target) {
+			return trash.Entry{}, fmt.Errorf("%s links to %s, which is not a registry skill; skiller only removes links it created", targetPath, target)
+		}
+		return trash.Entry{}, os.Remove(targetPath)
+	}
+
 	info, err := os.Stat(targetPath)
 	if err != nil {
-		return err
+		return trash.Entry{}, err
 	}
 	if !info.IsDir() {
-		return errors.New("target path is not a directory")
+		return trash.Entry{}, errors.New("target path is not a directory")
 	}
 
 	markerPath := filepath.Join(targetPath, "SKILL.md")
 	markerInfo, err := os.Stat(markerPath)
 	if err != nil {
-		return fmt.Errorf("target is not a valid installed skill: %w", err)
+		return trash.Entry{}, fmt.Errorf("target is not a valid installed skill: %w", err)
 	}
 	if markerInfo.IsDir() {
-		return errors.New("invalid skill marker")
+		return trash.Entry{}, errors.New("invalid skill marker")
 	}
 
-	return os.RemoveAll(targetPath)
+	return trash.Move(targetPath, targetPath, trash.ReasonUninstall)
 }
 
 func nextAvailableDestination(harnessPath, skillName string) (string, string) {
@@ -107,6 +261,6 @@ func nextAvailableDestination(harnessPath, skillName string) (string, string) {
 }
 
 func exists(path string) bool {
-	_, err := os.Stat(path)
+	_, err := os.Lstat(path)
 	return err == nil
 }
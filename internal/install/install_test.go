@@ -1,9 +1,17 @@
 package install
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"skiller/internal/adapter"
+	"skiller/internal/config"
+	"skiller/internal/fsutil"
+	"skiller/internal/provenance"
+	"skiller/internal/trash"
 )
 
 func TestInstallCopiesEntireSkillFolder(t *testing.T) {
@@ -84,7 +92,38 @@ func TestInstallConflictHandling(t *testing.T) {
 	}
 }
 
+func TestInstallWritesProvenance(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "alpha")
+	harness := filepath.Join(root, "harness")
+
+	if err := os.MkdirAll(source, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "SKILL.md"), []byte("# alpha"), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+
+	record := provenance.Record{RegistryID: "abc", Source: root, SourcePath: "alpha", ContentHash: "sha256:x"}
+	result, err := InstallSkillWithOptions(source, harness, ConflictSkip, Options{Provenance: &record})
+	if err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	loaded, ok, err := provenance.Read(result.Destination)
+	if err != nil || !ok {
+		t.Fatalf("expected provenance sidecar: ok=%v err=%v", ok, err)
+	}
+	if loaded.RegistryID != "abc" || loaded.SchemaVersion != provenance.SchemaVersion {
+		t.Fatalf("unexpected provenance: %#v", loaded)
+	}
+	if _, err := os.Stat(filepath.Join(source, provenance.FileName)); !os.IsNotExist(err) {
+		t.Fatalf("expected source folder to stay untouched")
+	}
+}
+
 func TestUninstallSkillRequiresMarker(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
 	root := t.TempDir()
 	harness := filepath.Join(root, "harness")
 	skill := filepath.Join(harness, "alpha")
@@ -93,7 +132,7 @@ func TestUninstallSkillRequiresMarker(t *testing.T) {
 		t.Fatalf("mkdir failed: %v", err)
 	}
 
-	if err := UninstallSkill(harness, "alpha"); err == nil {
+	if _, err := UninstallSkill(harness, "alpha", nil); err == nil {
 		t.Fatalf("expected uninstall to fail without SKILL.md")
 	}
 
@@ -101,7 +140,7 @@ func TestUninstallSkillRequiresMarker(t *testing.T) {
 		t.Fatalf("write marker failed: %v", err)
 	}
 
-	if err := UninstallSkill(harness, "alpha"); err != nil {
+	if _, err := UninstallSkill(harness, "alpha", nil); err != nil {
 		t.Fatalf("expected uninstall to succeed with marker: %v", err)
 	}
 
@@ -109,3 +148,427 @@ func TestUninstallSkillRequiresMarker(t *testing.T) {
 		t.Fatalf("expected skill directory removed")
 	}
 }
+
+func TestInstallSymlinkModeLinksToSource(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "registry", "alpha")
+	harness := filepath.Join(root, "harness")
+
+	if err := os.MkdirAll(source, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "SKILL.md"), []byte("# alpha"), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+
+	record := provenance.Record{RegistryID: "abc"}
+	result, err := InstallSkillWithOptions(source, harness, ConflictSkip, Options{Mode: config.InstallModeSymlink, Provenance: &record})
+	if err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	target, err := os.Readlink(result.Destination)
+	if err != nil || target != source {
+		t.Fatalf("expected link to %s, got %q (%v)", source, target, err)
+	}
+	if _, err := os.Stat(filepath.Join(source, provenance.FileName)); !os.IsNotExist(err) {
+		t.Fatalf("expected no sidecar to be written through the link")
+	}
+
+	if err := os.WriteFile(filepath.Join(source, "SKILL.md"), []byte("# alpha v2"), 0o644); err != nil {
+		t.Fatalf("edit source failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(result.Destination, "SKILL.md"))
+	if err != nil || string(content) != "# alpha v2" {
+		t.Fatalf("expected live content through link, got %q (%v)", content, err)
+	}
+
+	if _, err := UninstallSkill(harness, "alpha", nil); err == nil {
+		t.Fatalf("expected a link outside every registry to be refused")
+	}
+	registries := []config.Registry{{Type: config.RegistryTypeLocal, Source: filepath.Join(root, "registry")}}
+	if _, err := UninstallSkill(harness, "alpha", registries); err != nil {
+		t.Fatalf("uninstall failed: %v", err)
+	}
+	if _, err := os.Lstat(result.Destination); !os.IsNotExist(err) {
+		t.Fatalf("expected link to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(source, "SKILL.md")); err != nil {
+		t.Fatalf("expected link target to be untouched: %v", err)
+	}
+}
+
+func TestInstallHardlinkModeSharesFiles(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "registry", "alpha")
+	harness := filepath.Join(root, "harness")
+
+	if err := os.MkdirAll(filepath.Join(source, "nested"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "SKILL.md"), []byte("# alpha"), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(source, "nested", "run.sh"), []byte("#!/bin/sh"), 0o755); err != nil {
+		t.Fatalf("write nested file failed: %v", err)
+	}
+
+	record := provenance.Record{RegistryID: "abc"}
+	result, err := InstallSkillWithOptions(source, harness, ConflictSkip, Options{Mode: config.InstallModeHardlink, Provenance: &record})
+	if err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	for _, name := range []string{"SKILL.md", "nested/run.sh"} {
+		sourceInfo, err := os.Stat(filepath.Join(source, name))
+		if err != nil {
+			t.Fatalf("stat source failed: %v", err)
+		}
+		installedInfo, err := os.Stat(filepath.Join(result.Destination, name))
+		if err != nil {
+			t.Fatalf("stat install failed: %v", err)
+		}
+		if !os.SameFile(sourceInfo, installedInfo) {
+			t.Fatalf("expected %s to be hard-linked", name)
+		}
+	}
+
+	loaded, ok, err := provenance.Read(result.Destination)
+	if err != nil || !ok || loaded.InstallMode != string(config.InstallModeHardlink) {
+		t.Fatalf("expected hardlink provenance, got %#v ok=%v err=%v", loaded, ok, err)
+	}
+	if _, err := os.Stat(filepath.Join(source, provenance.FileName)); !os.IsNotExist(err) {
+		t.Fatalf("expected source folder to stay untouched")
+	}
+}
+
+func writeVersionedSkill(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+}
+
+func assertInstalledContent(t *testing.T, harness, want string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(harness, "alpha", "SKILL.md"))
+	if err != nil {
+		t.Fatalf("expected installed skill to survive: %v", err)
+	}
+	if string(data) != want {
+		t.Fatalf("expected %q, got %q", want, data)
+	}
+
+	entries, err := os.ReadDir(harness)
+	if err != nil {
+		t.Fatalf("read harness failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected staging directories to be cleaned up, got %d entries", len(entries))
+	}
+}
+
+func TestOverwriteKeepsPreviousVersionWhenCopyFails(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	source := filepath.Join(root, "alpha")
+	harness := filepath.Join(root, "harness")
+	writeVersionedSkill(t, source, "# v1")
+
+	if _, err := InstallSkill(source, harness, ConflictSkip); err != nil {
+		t.Fatalf("initial install failed: %v", err)
+	}
+	writeVersionedSkill(t, source, "# v2")
+
+	copyDir = func(src, dst string) error {
+		if err := fsutil.CopyDir(src, dst); err != nil {
+			return err
+		}
+		return errors.New("disk full")
+	}
+	t.Cleanup(func() { copyDir = fsutil.CopyDir })
+
+	if _, err := InstallSkill(source, harness, ConflictOverwrite); err == nil {
+		t.Fatalf("expected injected copy failure")
+	}
+	assertInstalledContent(t, harness, "# v1")
+
+	copyDir = fsutil.CopyDir
+	if _, err := InstallSkill(source, harness, ConflictOverwrite); err != nil {
+		t.Fatalf("overwrite failed: %v", err)
+	}
+	assertInstalledContent(t, harness, "# v2")
+}
+
+func TestOverwriteRollsBackWhenSwapFails(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	source := filepath.Join(root, "alpha")
+	harness := filepath.Join(root, "harness")
+	writeVersionedSkill(t, source, "# v1")
+
+	if _, err := InstallSkill(source, harness, ConflictSkip); err != nil {
+		t.Fatalf("initial install failed: %v", err)
+	}
+	writeVersionedSkill(t, source, "# v2")
+
+	calls := 0
+	rename = func(from, to string) error {
+		calls++
+		if calls == 2 {
+			return errors.New("injected rename failure")
+		}
+		return os.Rename(from, to)
+	}
+	t.Cleanup(func() { rename = os.Rename })
+
+	if _, err := InstallSkill(source, harness, ConflictOverwrite); err == nil {
+		t.Fatalf("expected injected swap failure")
+	}
+	if calls != 3 {
+		t.Fatalf("expected move aside, swap and rollback renames, got %d", calls)
+	}
+	assertInstalledContent(t, harness, "# v1")
+}
+
+func TestFailedRollbackKeepsBackup(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	source := filepath.Join(root, "alpha")
+	harness := filepath.Join(root, "harness")
+	writeVersionedSkill(t, source, "# v1")
+
+	if _, err := InstallSkill(source, harness, ConflictSkip); err != nil {
+		t.Fatalf("initial install failed: %v", err)
+	}
+
+	calls := 0
+	rename = func(from, to string) error {
+		calls++
+		if calls > 1 {
+			return errors.New("injected rename failure")
+		}
+		return os.Rename(from, to)
+	}
+	t.Cleanup(func() { rename = os.Rename })
+
+	_, err := InstallSkill(source, harness, ConflictOverwrite)
+	var rollbackErr *rollbackError
+	if !errors.As(err, &rollbackErr) {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(rollbackErr.backup, "SKILL.md")); err != nil {
+		t.Fatalf("expected previous version to be kept at %s: %v", rollbackErr.backup, err)
+	}
+}
+
+func TestOverwriteMovesPreviousVersionToTrash(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	source := filepath.Join(root, "alpha")
+	harness := filepath.Join(root, "harness")
+	writeVersionedSkill(t, source, "# v1")
+
+	if _, err := InstallSkill(source, harness, ConflictSkip); err != nil {
+		t.Fatalf("initial install failed: %v", err)
+	}
+	writeVersionedSkill(t, source, "# v2")
+
+	result, err := InstallSkill(source, harness, ConflictOverwrite)
+	if err != nil {
+		t.Fatalf("overwrite failed: %v", err)
+	}
+	if result.Trashed == nil || result.Trashed.OriginalPath != result.Destination || result.Trashed.Reason != trash.ReasonOverwrite {
+		t.Fatalf("expected previous version in the trash, got %#v", result.Trashed)
+	}
+
+	if _, err := trash.Restore(result.Trashed.ID, true); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	assertInstalledContent(t, harness, "# v1")
+}
+
+func TestAdaptedInstallsRenderAndUninstall(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	alpha := filepath.Join(root, "alpha")
+	beta := filepath.Join(root, "beta")
+	writeVersionedSkill(t, alpha, "---\ndescription: Alpha rules\n---\n# Alpha\n\nUse tabs.\n")
+	writeVersionedSkill(t, beta, "# Beta\n")
+
+	rules := filepath.Join(root, "rules")
+	result, err := InstallSkillWithOptions(alpha, rules, ConflictSkip, Options{Format: adapter.FormatCursorRule})
+	if err != nil || !result.Installed {
+		t.Fatalf("cursor install failed: %#v %v", result, err)
+	}
+	data, err := os.ReadFile(filepath.Join(rules, "alpha.mdc"))
+	if err != nil || !strings.Contains(string(data), `description: "Alpha rules"`) || !strings.Contains(string(data), "Use tabs.") {
+		t.Fatalf("unexpected rule file %q (%v)", data, err)
+	}
+	result, err = InstallSkillWithOptions(alpha, rules, ConflictOverwrite, Options{Format: adapter.FormatCursorRule})
+	if err != nil || result.Trashed == nil {
+		t.Fatalf("expected overwrite to trash the previous rule: %#v %v", result, err)
+	}
+
+	agents := filepath.Join(root, "codex")
+	if err := os.MkdirAll(agents, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(agents, adapter.AgentsFileName), []byte("# House rules\n"), 0o644); err != nil {
+		t.Fatalf("write agents file failed: %v", err)
+	}
+	for _, source := range []string{alpha, beta} {
+		if _, err := InstallSkillWithOptions(source, agents, ConflictSkip, Options{Format: adapter.FormatAgentsMD}); err != nil {
+			t.Fatalf("agents install failed: %v", err)
+		}
+	}
+	result, err = InstallSkillWithOptions(beta, agents, ConflictSkip, Options{Format: adapter.FormatAgentsMD})
+	if err != nil || !result.Conflict || result.Installed {
+		t.Fatalf("expected an existing section to conflict: %#v %v", result, err)
+	}
+
+	if _, err := UninstallSkill(agents, "alpha", nil); err != nil {
+		t.Fatalf("uninstall section failed: %v", err)
+	}
+	data, err = os.ReadFile(filepath.Join(agents, adapter.AgentsFileName))
+	if err != nil {
+		t.Fatalf("read agents file failed: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "# House rules\n") || strings.Contains(string(data), "Alpha") || !strings.Contains(string(data), "## beta") {
+		t.Fatalf("expected only the alpha section removed, got:\n%s", data)
+	}
+
+	entry, err := UninstallSkill(rules, "alpha", nil)
+	if err != nil || entry.ID == "" {
+		t.Fatalf("expected the rule file in the trash: %#v %v", entry, err)
+	}
+	if _, err := os.Stat(filepath.Join(rules, "alpha.mdc")); !os.IsNotExist(err) {
+		t.Fatalf("expected rule file removed")
+	}
+}
+
+func TestAdaptedOverwriteRollsBackWhenSwapFails(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	source := filepath.Join(root, "alpha")
+	rules := filepath.Join(root, "rules")
+	opts := Options{Format: adapter.FormatCursorRule}
+	writeVersionedSkill(t, source, "# v1\n")
+
+	if _, err := InstallSkillWithOptions(source, rules, ConflictSkip, opts); err != nil {
+		t.Fatalf("initial install failed: %v", err)
+	}
+	writeVersionedSkill(t, source, "# v2\n")
+
+	calls := 0
+	rename = func(from, to string) error {
+		calls++
+		if calls == 2 {
+			return errors.New("injected rename failure")
+		}
+		return os.Rename(from, to)
+	}
+	t.Cleanup(func() { rename = os.Rename })
+
+	if _, err := InstallSkillWithOptions(source, rules, ConflictOverwrite, opts); err == nil {
+		t.Fatalf("expected injected swap failure")
+	}
+	data, err := os.ReadFile(filepath.Join(rules, "alpha.mdc"))
+	if err != nil || !strings.Contains(string(data), "# v1") {
+		t.Fatalf("expected the previous rule to be restored, got %q (%v)", data, err)
+	}
+	if entries, err := trash.List(); err != nil || len(entries) != 0 {
+		t.Fatalf("expected nothing in the trash, got %v (%v)", entries, err)
+	}
+}
+
+func TestSharedFileChangesGoToTheTrash(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	alpha := filepath.Join(root, "alpha")
+	writeVersionedSkill(t, alpha, "# Alpha v1\n")
+	agents := filepath.Join(root, "codex")
+	agentsFile := filepath.Join(agents, adapter.AgentsFileName)
+	opts := Options{Format: adapter.FormatAgentsMD}
+
+	if _, err := InstallSkillWithOptions(alpha, agents, ConflictSkip, opts); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	writeVersionedSkill(t, alpha, "# Alpha v2\n")
+	result, err := InstallSkillWithOptions(alpha, agents, ConflictOverwrite, opts)
+	if err != nil || result.Trashed == nil || result.Trashed.OriginalPath != agentsFile {
+		t.Fatalf("expected the previous shared file in the trash: %#v %v", result, err)
+	}
+
+	entry, err := UninstallSkill(agents, "alpha", nil)
+	if err != nil || entry.ID == "" || entry.Reason != trash.ReasonUninstall {
+		t.Fatalf("expected the removed shared file in the trash: %#v %v", entry, err)
+	}
+	if _, err := os.Stat(agentsFile); !os.IsNotExist(err) {
+		t.Fatalf("expected the emptied shared file removed, got %v", err)
+	}
+
+	if _, err := trash.Restore(entry.ID, false); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	data, err := os.ReadFile(agentsFile)
+	if err != nil || !strings.Contains(string(data), "Alpha v2") {
+		t.Fatalf("expected the restored shared file, got %q (%v)", data, err)
+	}
+}
+
+func TestSharedFileUninstallTrashesOnlyTheSection(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	root := t.TempDir()
+	agents := filepath.Join(root, "codex")
+	agentsFile := filepath.Join(agents, adapter.AgentsFileName)
+	opts := Options{Format: adapter.FormatAgentsMD}
+	for _, name := range []string{"alpha", "beta", "gamma"} {
+		writeVersionedSkill(t, filepath.Join(root, name), "# "+name+" rules\n")
+	}
+	for _, name := range []string{"alpha", "beta"} {
+		if _, err := InstallSkillWithOptions(filepath.Join(root, name), agents, ConflictSkip, opts); err != nil {
+			t.Fatalf("install %s failed: %v", name, err)
+		}
+	}
+
+	entry, err := UninstallSkill(agents, "alpha", nil)
+	if err != nil || entry.Name != "alpha" || entry.Section != "alpha" {
+		t.Fatalf("expected the alpha section in the trash: %#v %v", entry, err)
+	}
+	if _, err := InstallSkillWithOptions(filepath.Join(root, "gamma"), agents, ConflictSkip, opts); err != nil {
+		t.Fatalf("install gamma failed: %v", err)
+	}
+
+	if _, err := trash.Restore(entry.ID, false); err != nil {
+		t.Fatalf("restore without force failed: %v", err)
+	}
+	data, err := os.ReadFile(agentsFile)
+	if err != nil {
+		t.Fatalf("read agents file failed: %v", err)
+	}
+	for _, want := range []string{"alpha rules", "beta rules", "gamma rules"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("expected %q after the restore, got:\n%s", want, data)
+		}
+	}
+
+	writeVersionedSkill(t, filepath.Join(root, "beta"), "# beta rules v2\n")
+	result, err := InstallSkillWithOptions(filepath.Join(root, "beta"), agents, ConflictOverwrite, opts)
+	if err != nil || result.Trashed == nil || result.Trashed.Section != "beta" {
+		t.Fatalf("expected the previous beta section in the trash: %#v %v", result, err)
+	}
+	if _, err := trash.Restore(result.Trashed.ID, false); !errors.Is(err, trash.ErrDestinationExists) {
+		t.Fatalf("expected restoring over the new section to need force, got %v", err)
+	}
+	if _, err := trash.Restore(result.Trashed.ID, true); err != nil {
+		t.Fatalf("forced restore failed: %v", err)
+	}
+	data, err = os.ReadFile(agentsFile)
+	if err != nil || strings.Contains(string(data), "v2") || !strings.Contains(string(data), "gamma rules") {
+		t.Fatalf("expected only the beta section rolled back, got %q (%v)", data, err)
+	}
+}
@@ -0,0 +1,370 @@
+package lockfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"skiller/internal/adapter"
+	"skiller/internal/config"
+	"skiller/internal/install"
+	"skiller/internal/provenance"
+	"skiller/internal/registrysync"
+	"skiller/internal/scan"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	ManifestFileName = "skiller.toml"
+	LockFileName     = "skiller.lock"
+	LockVersion      = 1
+)
+
+type Manifest struct {
+	Registries []config.Registry `toml:"registries"`
+	Skills     []ManifestSkill   `toml:"skills"`
+}
+
+type ManifestSkill struct {
+	Registry string `toml:"registry"`
+	Skill    string `toml:"skill"`
+	Ref      string `toml:"ref,omitempty"`
+	Harness  string `toml:"harness"`
+	Mode     string `toml:"mode,omitempty"`
+	Format   string `toml:"format,omitempty"`
+}
+
+type Lock struct {
+	Version int           `toml:"version"`
+	Skills  []LockedSkill `toml:"skills"`
+}
+
+type LockedSkill struct {
+	Registry    string              `toml:"registry"`
+	Type        config.RegistryType `toml:"type"`
+	Source      string              `toml:"source"`
+	Ref         string              `toml:"ref,omitempty"`
+	Subdir      string              `toml:"subdir,omitempty"`
+	Commit      string              `toml:"commit,omitempty"`
+	Skill       string              `toml:"skill"`
+	Path        string              `toml:"path"`
+	Harness     string              `toml:"harness"`
+	Mode        config.InstallMode  `toml:"mode,omitempty"`
+	Format      adapter.Format      `toml:"format,omitempty"`
+	ContentHash string              `toml:"content_hash"`
+}
+
+type Fetcher func(registry config.Registry) error
+
+type HarnessFormat func(harness string) adapter.Format
+
+type InstalledSkill struct {
+	Locked LockedSkill
+	Result install.InstallResult
+}
+
+func LoadManifest(path string) (*Manifest, error) {
+	manifest := &Manifest{}
+	if _, err := toml.DecodeFile(path, manifest); err != nil {
+		return nil, err
+	}
+
+	names := map[string]struct{}{}
+	for _, registry := range manifest.Registries {
+		name := strings.TrimSpace(registry.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%s: every registry needs a name", path)
+		}
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("%s: duplicate registry name %s", path, name)
+		}
+		names[name] = struct{}{}
+	}
+
+	for _, skill := range manifest.Skills {
+		if _, ok := names[strings.TrimSpace(skill.Registry)]; !ok {
+			return nil, fmt.Errorf("%s: skill %s references unknown registry %q", path, skill.Skill, skill.Registry)
+		}
+		if strings.TrimSpace(skill.Skill) == "" || strings.TrimSpace(skill.Harness) == "" {
+			return nil, fmt.Errorf("%s: every skill needs a skill name and a harness", path)
+		}
+		if _, err := config.ParseInstallMode(skill.Mode); err != nil {
+			return nil, fmt.Errorf("%s: skill %s: %w", path, skill.Skill, err)
+		}
+		if _, err := adapter.ParseFormat(skill.Format); err != nil {
+			return nil, fmt.Errorf("%s: skill %s: %w", path, skill.Skill, err)
+		}
+	}
+
+	return manifest, nil
+}
+
+func LoadLock(path string) (*Lock, error) {
+	lock := &Lock{}
+	if _, err := toml.DecodeFile(path, lock); err != nil {
+		return nil, err
+	}
+	if lock.Version != LockVersion {
+		return nil, fmt.Errorf("%s: unsupported lock version %d", path, lock.Version)
+	}
+	return lock, nil
+}
+
+func (l *Lock) Save(path string) error {
+	l.Version = LockVersion
+
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by skiller lock. Do not edit by hand.\n")
+	if err := toml.NewEncoder(&buf).Encode(l); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (l LockedSkill) RegistryConfig(dir string) (config.Registry, error) {
+	return resolveRegistry(config.Registry{
+		Name:   l.Registry,
+		Type:   l.Type,
+		Source: l.Source,
+		Ref:    l.Ref,
+		Subdir: l.Subdir,
+	}, dir)
+}
+
+func (l LockedSkill) PinnedRegistryConfig(dir string) (config.Registry, error) {
+	pinned := l
+	if l.Type == config.RegistryTypeGit && l.Commit != "" {
+		pinned.Ref = l.Commit
+	}
+	return pinned.RegistryConfig(dir)
+}
+
+func Resolve(manifest *Manifest, dir string, fetch Fetcher, formats HarnessFormat) (*Lock, error) {
+	registries := map[string]config.Registry{}
+	for _, registry := range manifest.Registries {
+		registries[strings.TrimSpace(registry.Name)] = registry
+	}
+
+	fetched := map[string]struct{}{}
+	lock := &Lock{Version: LockVersion}
+	for _, wanted := range manifest.Skills {
+		declared := registries[strings.TrimSpace(wanted.Registry)]
+		if strings.TrimSpace(wanted.Ref) != "" {
+			declared.Ref = wanted.Ref
+		}
+
+		registry, err := resolveRegistry(declared, dir)
+		if err != nil {
+			return nil, fmt.Errorf("registry %s: %w", wanted.Registry, err)
+		}
+
+		if _, ok := fetched[registry.ID]; !ok && registry.IsRemote() {
+			if err := fetch(registry); err != nil {
+				return nil, fmt.Errorf("sync %s: %w", wanted.Registry, err)
+			}
+			fetched[registry.ID] = struct{}{}
+		}
+
+		skill, err := findSkill(registry, wanted.Skill)
+		if err != nil {
+			return nil, err
+		}
+		if err := registrysync.FetchSkill(registry, skill.Path); err != nil {
+			return nil, err
+		}
+
+		record, err := provenance.ForSkill(registry, skill)
+		if err != nil {
+			return nil, err
+		}
+
+		commit := ""
+		if registry.IsRemote() {
+			commit = record.Commit
+		}
+
+		mode, err := config.ParseInstallMode(wanted.Mode)
+		if err != nil {
+			return nil, err
+		}
+		if mode == config.InstallModeCopy {
+			mode = ""
+		}
+
+		format, err := adapter.ParseFormat(wanted.Format)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(wanted.Format) == "" && formats != nil {
+			harness, err := resolvePath(wanted.Harness, dir)
+			if err != nil {
+				return nil, err
+			}
+			format = formats(harness)
+		}
+
+		lock.Skills = append(lock.Skills, LockedSkill{
+			Registry:    wanted.Registry,
+			Type:        registry.Type,
+			Source:      declared.Source,
+			Ref:         registry.Ref,
+			Subdir:      registry.Subdir,
+			Commit:      commit,
+			Skill:       skill.Name,
+			Path:        record.SourcePath,
+			Harness:     wanted.Harness,
+			Mode:        mode,
+			Format:      format,
+			ContentHash: record.ContentHash,
+		})
+	}
+
+	return lock, nil
+}
+
+func InstallFrozen(lock *Lock, dir string, fetch Fetcher, formats HarnessFormat) ([]InstalledSkill, error) {
+	type plannedInstall struct {
+		locked  LockedSkill
+		skill   scan.Skill
+		record  provenance.Record
+		harness string
+	}
+
+	fetched := map[string]struct{}{}
+	plan := make([]plannedInstall, 0, len(lock.Skills))
+	for _, locked := range lock.Skills {
+		registry, err := locked.PinnedRegistryConfig(dir)
+		if err != nil {
+			return nil, fmt.Errorf("registry %s: %w", locked.Registry, err)
+		}
+
+		if _, ok := fetched[registry.ID]; !ok && registry.IsRemote() {
+			if err := fetch(registry); err != nil {
+				return nil, fmt.Errorf("sync %s: %w", locked.Registry, err)
+			}
+			fetched[registry.ID] = struct{}{}
+		}
+
+		skill, err := findSkill(registry, locked.Path)
+		if err != nil {
+			return nil, err
+		}
+		if err := registrysync.FetchSkill(registry, skill.Path); err != nil {
+			return nil, err
+		}
+
+		record, err := provenance.ForSkill(registry, skill)
+		if err != nil {
+			return nil, err
+		}
+		if locked.Commit != "" && record.Commit != locked.Commit {
+			return nil, fmt.Errorf("%s/%s: registry is at commit %s but the lock pins %s", locked.Registry, locked.Skill, record.ShortCommit(), locked.Commit)
+		}
+		if record.ContentHash != locked.ContentHash {
+			return nil, fmt.Errorf("%s/%s: content hash %s does not match locked %s", locked.Registry, locked.Skill, record.ContentHash, locked.ContentHash)
+		}
+
+		harness, err := resolvePath(locked.Harness, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		if locked.Format == "" && formats != nil {
+			locked.Format = formats(harness)
+		}
+
+		plan = append(plan, plannedInstall{locked: locked, skill: skill, record: record, harness: harness})
+	}
+
+	installed := make([]InstalledSkill, 0, len(plan))
+	for _, planned := range plan {
+		record := planned.record
+		result, err := install.InstallSkillWithOptions(planned.skill.Path, planned.harness, install.ConflictOverwrite, install.Options{
+			Name:       planned.locked.Skill,
+			Mode:       planned.locked.Mode,
+			Format:     planned.locked.Format,
+			Provenance: &record,
+		})
+		if err != nil {
+			return installed, fmt.Errorf("%s/%s: %w", planned.locked.Registry, planned.locked.Skill, err)
+		}
+		installed = append(installed, InstalledSkill{Locked: planned.locked, Result: result})
+	}
+
+	return installed, nil
+}
+
+func findSkill(registry config.Registry, reference string) (scan.Skill, error) {
+	root, err := config.RegistryScanRoot(registry)
+	if err != nil {
+		return scan.Skill{}, err
+	}
+
+	skills, err := scan.ScanRegistry(root)
+	if err != nil {
+		return scan.Skill{}, fmt.Errorf("registry %s: %w", registry.DisplayName(), err)
+	}
+
+	trimmed := strings.Trim(filepath.ToSlash(strings.TrimSpace(reference)), "/")
+	var matches []scan.Skill
+	for _, skill := range skills {
+		rel, err := filepath.Rel(root, skill.Path)
+		if err != nil {
+			continue
+		}
+		if filepath.ToSlash(rel) == trimmed {
+			return skill, nil
+		}
+		if skill.Name == trimmed {
+			matches = append(matches, skill)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return scan.Skill{}, fmt.Errorf("skill %s not found in registry %s", reference, registry.DisplayName())
+	case 1:
+		return matches[0], nil
+	default:
+		return scan.Skill{}, fmt.Errorf("skill %s is ambiguous in registry %s, use its path", reference, registry.DisplayName())
+	}
+}
+
+func resolveRegistry(registry config.Registry, dir string) (config.Registry, error) {
+	if registry.Type == "" && !config.IsGitSource(registry.Source) {
+		registry.Type = config.RegistryTypeLocal
+	}
+	if registry.Type == config.RegistryTypeLocal {
+		source, err := resolvePath(registry.Source, dir)
+		if err != nil {
+			return config.Registry{}, err
+		}
+		registry.Source = source
+	}
+	registry.ID = ""
+	return config.NormalizeRegistry(registry)
+}
+
+func resolvePath(path, dir string) (string, error) {
+	trimmed := strings.TrimSpace(path)
+	if trimmed == "" {
+		return "", errors.New("path is empty")
+	}
+	if filepath.IsAbs(trimmed) || strings.HasPrefix(trimmed, "~") || strings.HasPrefix(trimmed, "$") {
+		return config.ExpandPath(trimmed)
+	}
+	return config.ExpandPath(filepath.Join(dir, trimmed))
+}
@@ -0,0 +1,244 @@
+package lockfile
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"skiller/internal/adapter"
+	"skiller/internal/config"
+	"skiller/internal/provenance"
+	"skiller/internal/registrysync"
+)
+
+func writeProject(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	skill := filepath.Join(dir, "skills", "tools", "alpha")
+	if err := os.MkdirAll(skill, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(skill, "SKILL.md"), []byte("# alpha"), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+
+	manifest := `[[registries]]
+name = "team"
+type = "local"
+source = "skills"
+
+[[skills]]
+registry = "team"
+skill = "alpha"
+harness = ".claude/skills"
+`
+	if err := os.WriteFile(filepath.Join(dir, ManifestFileName), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("write manifest failed: %v", err)
+	}
+
+	return dir
+}
+
+func noFetch(t *testing.T) Fetcher {
+	return func(registry config.Registry) error {
+		t.Fatalf("unexpected fetch of %s", registry.Source)
+		return nil
+	}
+}
+
+func TestResolveAndInstallFrozen(t *testing.T) {
+	dir := writeProject(t)
+
+	manifest, err := LoadManifest(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		t.Fatalf("load manifest failed: %v", err)
+	}
+
+	lock, err := Resolve(manifest, dir, noFetch(t), nil)
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if len(lock.Skills) != 1 || lock.Skills[0].Path != "tools/alpha" || lock.Skills[0].Source != "skills" {
+		t.Fatalf("unexpected lock: %#v", lock.Skills)
+	}
+
+	lockPath := filepath.Join(dir, LockFileName)
+	if err := lock.Save(lockPath); err != nil {
+		t.Fatalf("save lock failed: %v", err)
+	}
+	if _, err := os.Stat(lockPath + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("expected no temporary lockfile to be left, got %v", err)
+	}
+
+	loaded, err := LoadLock(lockPath)
+	if err != nil {
+		t.Fatalf("load lock failed: %v", err)
+	}
+	if loaded.Skills[0] != lock.Skills[0] {
+		t.Fatalf("expected lock round-trip, got %#v", loaded.Skills[0])
+	}
+
+	installed, err := InstallFrozen(loaded, dir, noFetch(t), nil)
+	if err != nil {
+		t.Fatalf("frozen install failed: %v", err)
+	}
+	if len(installed) != 1 {
+		t.Fatalf("expected one installed skill, got %d", len(installed))
+	}
+
+	destination := filepath.Join(dir, ".claude", "skills", "alpha")
+	record, ok, err := provenance.Read(destination)
+	if err != nil || !ok {
+		t.Fatalf("expected provenance in %s: ok=%v err=%v", destination, ok, err)
+	}
+	if record.ContentHash != lock.Skills[0].ContentHash {
+		t.Fatalf("expected installed hash to match lock")
+	}
+}
+
+func TestInstallFrozenRejectsChangedContent(t *testing.T) {
+	dir := writeProject(t)
+
+	manifest, err := LoadManifest(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		t.Fatalf("load manifest failed: %v", err)
+	}
+	lock, err := Resolve(manifest, dir, noFetch(t), nil)
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "skills", "tools", "alpha", "SKILL.md"), []byte("# changed"), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+
+	_, err = InstallFrozen(lock, dir, noFetch(t), nil)
+	if err == nil || !strings.Contains(err.Error(), "does not match locked") {
+		t.Fatalf("expected content hash mismatch, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".claude", "skills", "alpha")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be installed on mismatch")
+	}
+}
+
+func TestInstallFrozenUsesTheHarnessFormat(t *testing.T) {
+	dir := writeProject(t)
+
+	manifest, err := LoadManifest(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		t.Fatalf("load manifest failed: %v", err)
+	}
+	cursor := func(string) adapter.Format { return adapter.FormatCursorRule }
+	lock, err := Resolve(manifest, dir, noFetch(t), cursor)
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if lock.Skills[0].Format != adapter.FormatCursorRule {
+		t.Fatalf("expected the harness format in the lock, got %q", lock.Skills[0].Format)
+	}
+
+	if _, err := InstallFrozen(lock, dir, noFetch(t), nil); err != nil {
+		t.Fatalf("frozen install failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".claude", "skills", "alpha.mdc")); err != nil {
+		t.Fatalf("expected a cursor rule: %v", err)
+	}
+
+	lock.Skills[0].Format = ""
+	if err := os.RemoveAll(filepath.Join(dir, ".claude")); err != nil {
+		t.Fatalf("remove harness failed: %v", err)
+	}
+	if _, err := InstallFrozen(lock, dir, noFetch(t), cursor); err != nil {
+		t.Fatalf("frozen install of an older lock failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".claude", "skills", "alpha.mdc")); err != nil {
+		t.Fatalf("expected an older lock to fall back to the harness format: %v", err)
+	}
+}
+
+func TestLoadManifestRejectsUnknownRegistry(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ManifestFileName)
+	manifest := `[[skills]]
+registry = "missing"
+skill = "alpha"
+harness = ".claude/skills"
+`
+	if err := os.WriteFile(path, []byte(manifest), 0o644); err != nil {
+		t.Fatalf("write manifest failed: %v", err)
+	}
+
+	if _, err := LoadManifest(path); err == nil {
+		t.Fatalf("expected unknown registry error")
+	}
+}
+
+func gitCommit(t *testing.T, repo, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Join(repo, "alpha"), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "alpha", "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	for _, args := range [][]string{{"add", "-A"}, {"commit", "--quiet", "-m", content}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=skiller", "GIT_AUTHOR_EMAIL=skiller@example.com",
+			"GIT_COMMITTER_NAME=skiller", "GIT_COMMITTER_EMAIL=skiller@example.com",
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v (%s)", args, err, output)
+		}
+	}
+}
+
+func TestInstallFrozenPinsLockedCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	upstream := t.TempDir()
+	if output, err := exec.Command("git", "init", "--quiet", "--initial-branch=main", upstream).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v (%s)", err, output)
+	}
+	gitCommit(t, upstream, "# alpha v1")
+
+	dir := t.TempDir()
+	manifest := &Manifest{
+		Registries: []config.Registry{{Name: "team", Source: "file://" + upstream, Ref: "main"}},
+		Skills:     []ManifestSkill{{Registry: "team", Skill: "alpha", Harness: "harness"}},
+	}
+	fetch := func(registry config.Registry) error {
+		_, err := registrysync.SyncRegistry(registry, false, time.Minute)
+		return err
+	}
+
+	lock, err := Resolve(manifest, dir, fetch, nil)
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if len(lock.Skills[0].Commit) != 40 {
+		t.Fatalf("expected locked commit, got %q", lock.Skills[0].Commit)
+	}
+
+	gitCommit(t, upstream, "# alpha v2")
+
+	if _, err := InstallFrozen(lock, dir, fetch, nil); err != nil {
+		t.Fatalf("frozen install failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "harness", "alpha", "SKILL.md"))
+	if err != nil || string(content) != "# alpha v1" {
+		t.Fatalf("expected locked content, got %q (%v)", content, err)
+	}
+}
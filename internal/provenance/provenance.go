@@ -0,0 +1,121 @@
+package provenance
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"skiller/internal/config"
+	"skiller/internal/fsutil"
+	"skiller/internal/registrysync"
+	"skiller/internal/scan"
+)
+
+const (
+	FileName      = ".skiller.json"
+	SchemaVersion = 1
+)
+
+type Record struct {
+	SchemaVersion int       `json:"schema_version"`
+	RegistryID    string    `json:"registry_id"`
+	RegistryName  string    `json:"registry_name"`
+	RegistryType  string    `json:"registry_type"`
+	Source        string    `json:"source"`
+	Ref           string    `json:"ref,omitempty"`
+	Commit        string    `json:"commit,omitempty"`
+	SourcePath    string    `json:"source_path"`
+	ContentHash   string    `json:"content_hash"`
+	InstallMode   string    `json:"install_mode,omitempty"`
+	InstalledAt   time.Time `json:"installed_at"`
+}
+
+func (r Record) ShortCommit() string {
+	if len(r.Commit) > 7 {
+		return r.Commit[:7]
+	}
+	return r.Commit
+}
+
+func (r Record) Summary() string {
+	summary := r.RegistryName
+	if r.Ref != "" {
+		summary += "#" + r.Ref
+	}
+	if r.Commit != "" {
+		summary += "@" + r.ShortCommit()
+	}
+	return summary
+}
+
+func ForSkill(registry config.Registry, skill scan.Skill) (Record, error) {
+	hash, err := HashSkill(skill.Path)
+	if err != nil {
+		return Record{}, err
+	}
+
+	scanRoot, err := config.RegistryScanRoot(registry)
+	if err != nil {
+		return Record{}, err
+	}
+
+	sourcePath, err := filepath.Rel(scanRoot, skill.Path)
+	if err != nil {
+		return Record{}, err
+	}
+
+	commit, err := registrysync.RegistryCommit(registry, scanRoot)
+	if err != nil {
+		if registry.IsRemote() {
+			return Record{}, err
+		}
+		commit = ""
+	}
+
+	return Record{
+		SchemaVersion: SchemaVersion,
+		RegistryID:    registry.ID,
+		RegistryName:  registry.DisplayName(),
+		RegistryType:  string(registry.Type),
+		Source:        registry.Source,
+		Ref:           registry.Ref,
+		Commit:        commit,
+		SourcePath:    filepath.ToSlash(sourcePath),
+		ContentHash:   hash,
+		InstalledAt:   time.Now().UTC().Truncate(time.Second),
+	}, nil
+}
+
+func HashSkill(skillPath string) (string, error) {
+	return fsutil.HashDir(skillPath, FileName)
+}
+
+func Read(skillPath string) (Record, bool, error) {
+	data, err := os.ReadFile(filepath.Join(skillPath, FileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Record{}, false, nil
+		}
+		return Record{}, false, err
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return Record{}, false, err
+	}
+	return record, true, nil
+}
+
+func Write(skillPath string, record Record) error {
+	if record.SchemaVersion == 0 {
+		record.SchemaVersion = SchemaVersion
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(skillPath, FileName), append(data, '\n'), 0o644)
+}
@@ -0,0 +1,73 @@
+package provenance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"skiller/internal/config"
+	"skiller/internal/scan"
+)
+
+func TestForSkillAndRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	skillPath := filepath.Join(root, "nested", "alpha")
+
+	if err := os.MkdirAll(skillPath, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(skillPath, "SKILL.md"), []byte("# alpha"), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+
+	registry := config.Registry{ID: "local1", Type: config.RegistryTypeLocal, Source: root}
+	record, err := ForSkill(registry, scan.Skill{Name: "alpha", Path: skillPath, Parent: root})
+	if err != nil {
+		t.Fatalf("for skill failed: %v", err)
+	}
+
+	if record.RegistryID != "local1" || record.SourcePath != "nested/alpha" {
+		t.Fatalf("unexpected record: %#v", record)
+	}
+	if record.ContentHash == "" || record.InstalledAt.IsZero() {
+		t.Fatalf("expected hash and install time: %#v", record)
+	}
+
+	if err := Write(skillPath, record); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	loaded, ok, err := Read(skillPath)
+	if err != nil || !ok {
+		t.Fatalf("read failed: ok=%v err=%v", ok, err)
+	}
+	if loaded != record {
+		t.Fatalf("expected %#v, got %#v", record, loaded)
+	}
+
+	hash, err := HashSkill(skillPath)
+	if err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	if hash != record.ContentHash {
+		t.Fatalf("expected sidecar to be excluded from content hash")
+	}
+
+	if err := os.WriteFile(filepath.Join(skillPath, "SKILL.md"), []byte("# changed"), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+	changed, err := HashSkill(skillPath)
+	if err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	if changed == record.ContentHash {
+		t.Fatalf("expected content change to change hash")
+	}
+}
+
+func TestReadMissingRecord(t *testing.T) {
+	_, ok, err := Read(t.TempDir())
+	if err != nil || ok {
+		t.Fatalf("expected missing record without error, got ok=%v err=%v", ok, err)
+	}
+}
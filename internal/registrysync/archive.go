@@ -0,0 +1,253 @@
+package registrysync
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"skiller/internal/config"
+)
+
+func syncArchive(ctx context.Context, registry config.Registry, repoPath string, report func(Phase)) (SyncResult, error) {
+	result := SyncResult{RepoPath: repoPath, PreviousCommit: previousCommit(registry), CommitsAhead: -1}
+
+	report(PhaseDownloading)
+	archive, checksum, err := downloadTemp(ctx, registry.Source, filepath.Dir(repoPath))
+	if err != nil {
+		return result, err
+	}
+	defer os.Remove(archive)
+	result.Commit = checksum
+
+	if checksum == result.PreviousCommit && isDir(repoPath) {
+		result.CommitsAhead = 0
+		return result, nil
+	}
+
+	report(PhaseExtracting)
+	staged, err := os.MkdirTemp(filepath.Dir(repoPath), "extract-*")
+	if err != nil {
+		return result, err
+	}
+	defer os.RemoveAll(staged)
+
+	if err := extractArchive(archive, staged); err != nil {
+		return result, &SyncError{Step: "extract", Err: err}
+	}
+	return result, replaceDir(staged, repoPath)
+}
+
+func openSource(ctx context.Context, source string) (io.ReadCloser, error) {
+	parsed, err := url.Parse(source)
+	if err != nil || len(parsed.Scheme) <= 1 {
+		return os.Open(source)
+	}
+
+	switch parsed.Scheme {
+	case "file":
+		return os.Open(parsed.Path)
+	case "http", "https":
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return nil, contextError(ctx, err)
+		}
+		if response.StatusCode != http.StatusOK {
+			response.Body.Close()
+			return nil, fmt.Errorf("GET %s: %s", source, response.Status)
+		}
+		return response.Body, nil
+	default:
+		return nil, fmt.Errorf("unsupported source scheme %q", parsed.Scheme)
+	}
+}
+
+func resolveSource(base, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if parsed, err := url.Parse(ref); err == nil && len(parsed.Scheme) > 1 {
+		return ref
+	}
+
+	if parsed, err := url.Parse(base); err == nil && len(parsed.Scheme) > 1 {
+		if relative, err := url.Parse(ref); err == nil {
+			return parsed.ResolveReference(relative).String()
+		}
+		return ref
+	}
+
+	if filepath.IsAbs(ref) {
+		return ref
+	}
+	return filepath.Join(filepath.Dir(base), filepath.FromSlash(ref))
+}
+
+func downloadTemp(ctx context.Context, source, dir string) (string, string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", err
+	}
+
+	reader, err := openSource(ctx, source)
+	if err != nil {
+		return "", "", &SyncError{Step: "download", Err: err}
+	}
+	defer reader.Close()
+
+	file, err := os.CreateTemp(dir, "download-*")
+	if err != nil {
+		return "", "", err
+	}
+
+	hash := sha256.New()
+	_, copyErr := io.Copy(io.MultiWriter(file, hash), reader)
+	closeErr := file.Close()
+	if err := errors.Join(copyErr, closeErr); err != nil {
+		os.Remove(file.Name())
+		return "", "", &SyncError{Step: "download", Err: contextError(ctx, err)}
+	}
+	return file.Name(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func extractArchive(path, dest string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header := make([]byte, 4)
+	n, _ := io.ReadFull(file, header)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return extractZip(path, dest)
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return extractTar(gz, dest)
+	default:
+		return extractTar(file, dest)
+	}
+}
+
+func extractTar(reader io.Reader, dest string) error {
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, err := archivePath(dest, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeEntry(target, archive, header.FileInfo().Mode()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractZip(path, dest string) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	for _, entry := range archive.File {
+		target, err := archivePath(dest, entry.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			reader, err := entry.Open()
+			if err != nil {
+				return err
+			}
+			err = writeEntry(target, reader, mode)
+			reader.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// archivePath refuses entry names that are absolute or climb out of dest, so
+// a crafted archive cannot write outside the extraction directory.
+func archivePath(dest, name string) (string, error) {
+	slashed := strings.ReplaceAll(name, "\\", "/")
+	clean := filepath.Clean(filepath.FromSlash(slashed))
+	if strings.HasPrefix(slashed, "/") || filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" ||
+		clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %q escapes the extraction directory", name)
+	}
+	return filepath.Join(dest, clean), nil
+}
+
+func writeEntry(target string, reader io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0o600)
+	if err != nil {
+		return err
+	}
+	_, copyErr := io.Copy(file, reader)
+	return errors.Join(copyErr, file.Close())
+}
+
+func replaceDir(staged, target string) error {
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(staged, target)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
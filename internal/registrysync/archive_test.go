@@ -0,0 +1,302 @@
+package registrysync
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"skiller/internal/config"
+	"skiller/internal/scan"
+)
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := archive.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("tar header failed: %v", err)
+		}
+		if _, err := archive.Write([]byte(content)); err != nil {
+			t.Fatalf("tar write failed: %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("tar close failed: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("zip create failed: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("zip write failed: %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("zip close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestSyncArchiveRegistryReextractsOnChecksumChange(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	var mu sync.Mutex
+	body := tarGz(t, map[string]string{"pack/review/SKILL.md": "---\nname: review\n---\n"})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	registry, err := config.NormalizeRegistry(config.Registry{Source: server.URL + "/skills.tar.gz"})
+	if err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+	if registry.Type != config.RegistryTypeArchive || registry.DisplayName() != "skills" {
+		t.Fatalf("unexpected registry: %+v (%s)", registry, registry.DisplayName())
+	}
+
+	first, err := SyncRegistry(registry, false, 0)
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	root, err := config.RegistryScanRoot(registry)
+	if err != nil {
+		t.Fatalf("scan root failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "pack", "review", "SKILL.md")); err != nil {
+		t.Fatalf("expected extracted skill: %v", err)
+	}
+
+	again, err := SyncRegistry(registry, false, 0)
+	if err != nil {
+		t.Fatalf("second sync failed: %v", err)
+	}
+	if again.Commit != first.Commit || again.CommitsAhead != 0 {
+		t.Fatalf("expected unchanged archive, got %+v after %+v", again, first)
+	}
+
+	mu.Lock()
+	body = tarGz(t, map[string]string{"pack/lint/SKILL.md": "---\nname: lint\n---\n"})
+	mu.Unlock()
+
+	changed, err := SyncRegistry(registry, false, 0)
+	if err != nil {
+		t.Fatalf("third sync failed: %v", err)
+	}
+	if changed.Commit == first.Commit || changed.PreviousCommit != first.Commit {
+		t.Fatalf("expected a new checksum, got %+v", changed)
+	}
+	if _, err := os.Stat(filepath.Join(root, "pack", "review")); !os.IsNotExist(err) {
+		t.Fatalf("expected the old extraction to be replaced, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "pack", "lint", "SKILL.md")); err != nil {
+		t.Fatalf("expected re-extracted skill: %v", err)
+	}
+
+	commit, err := RegistryCommit(registry, root)
+	if err != nil || commit != changed.Commit {
+		t.Fatalf("expected registry commit %s, got %q (%v)", changed.Commit, commit, err)
+	}
+}
+
+func TestExtractArchiveRejectsPathTraversal(t *testing.T) {
+	base := t.TempDir()
+	dest := filepath.Join(base, "dest")
+
+	for name, data := range map[string][]byte{
+		"zip":        zipArchive(t, map[string]string{"../escaped": "x"}),
+		"tar.gz":     tarGz(t, map[string]string{"skills/../../escaped": "x"}),
+		"abs.tar.gz": tarGz(t, map[string]string{"/abs/escaped": "x"}),
+	} {
+		archive := filepath.Join(base, "archive."+name)
+		if err := os.WriteFile(archive, data, 0o644); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		err := extractArchive(archive, dest)
+		if err == nil || !strings.Contains(err.Error(), "escapes") {
+			t.Fatalf("%s: expected traversal to be rejected, got %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(base, "escaped")); !os.IsNotExist(err) {
+			t.Fatalf("%s: file written outside the destination", name)
+		}
+	}
+}
+
+func TestIndexRegistryFetchesSkillsOnInstall(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	skillZip := zipArchive(t, map[string]string{
+		"review/SKILL.md":     "---\nname: review\ndescription: Real review skill\n---\nReview carefully.\n",
+		"review/checklist.md": "- tests\n",
+	})
+	sum := sha256.Sum256(skillZip)
+	checksum := hex.EncodeToString(sum[:])
+
+	var downloads atomic.Int32
+	catalog := fmt.Sprintf(`{"skills": [
+		{"name": "review", "path": "quality/review", "description": "Reviews code", "version": "1.2.0", "tags": ["go", "review"], "url": "archives/review.zip", "sha256": %q},
+		{"name": "broken", "description": "Bad checksum", "url": "archives/review.zip", "sha256": "%s"}
+	]}`, checksum, strings.Repeat("0", 64))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.json":
+			w.Write([]byte(catalog))
+		case "/archives/review.zip":
+			downloads.Add(1)
+			w.Write(skillZip)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	registry, err := config.NormalizeRegistry(config.Registry{Source: server.URL + "/index.json"})
+	if err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+	if registry.Type != config.RegistryTypeIndex {
+		t.Fatalf("expected an index registry, got %s", registry.Type)
+	}
+
+	if _, err := SyncRegistry(registry, false, 0); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if downloads.Load() != 0 {
+		t.Fatalf("expected browsing to download no skills, got %d downloads", downloads.Load())
+	}
+
+	root, err := config.RegistryScanRoot(registry)
+	if err != nil {
+		t.Fatalf("scan root failed: %v", err)
+	}
+	skills, err := scan.ScanRegistry(root)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(skills) != 2 {
+		t.Fatalf("expected 2 listed skills, got %+v", skills)
+	}
+	review := skills[1]
+	if review.Name != "review" || review.Metadata.Description != "Reviews code" || review.Metadata.Version != "1.2.0" || len(review.Metadata.Tags) != 2 {
+		t.Fatalf("expected catalog metadata, got %+v", review)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := FetchSkill(registry, review.Path); err != nil {
+			t.Fatalf("fetch failed: %v", err)
+		}
+	}
+	if downloads.Load() != 1 {
+		t.Fatalf("expected one download, got %d", downloads.Load())
+	}
+	data, err := os.ReadFile(filepath.Join(review.Path, "checklist.md"))
+	if err != nil || string(data) != "- tests\n" {
+		t.Fatalf("expected fetched skill content, got %q (%v)", data, err)
+	}
+
+	if _, err := SyncRegistry(registry, false, 0); err != nil {
+		t.Fatalf("resync failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(review.Path, "checklist.md")); err != nil {
+		t.Fatalf("expected fetched skill to survive an unchanged resync: %v", err)
+	}
+
+	err = FetchSkill(registry, skills[0].Path)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+}
+
+func TestIndexRegistryKeepsFetchedSkillsWithoutChecksum(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	skillZip := zipArchive(t, map[string]string{
+		"review/SKILL.md":     "---\nname: review\n---\nReview carefully.\n",
+		"review/checklist.md": "- tests\n",
+	})
+
+	var downloads atomic.Int32
+	var version atomic.Value
+	version.Store("1.0.0")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.json":
+			fmt.Fprintf(w, `{"skills": [{"name": "review", "version": %q, "url": "review.zip"}]}`, version.Load())
+		case "/review.zip":
+			downloads.Add(1)
+			w.Write(skillZip)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	registry, err := config.NormalizeRegistry(config.Registry{Source: server.URL + "/index.json"})
+	if err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+	if _, err := SyncRegistry(registry, false, 0); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	root, err := config.RegistryScanRoot(registry)
+	if err != nil {
+		t.Fatalf("scan root failed: %v", err)
+	}
+	skillPath := filepath.Join(root, "review")
+	if err := FetchSkill(registry, skillPath); err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+
+	if _, err := SyncRegistry(registry, false, 0); err != nil {
+		t.Fatalf("resync failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(skillPath, "checklist.md")); err != nil {
+		t.Fatalf("expected fetched skill to survive an unchanged resync: %v", err)
+	}
+	if err := FetchSkill(registry, skillPath); err != nil {
+		t.Fatalf("refetch failed: %v", err)
+	}
+	if downloads.Load() != 1 {
+		t.Fatalf("expected one download while the entry is unchanged, got %d", downloads.Load())
+	}
+
+	version.Store("1.1.0")
+	if _, err := SyncRegistry(registry, false, 0); err != nil {
+		t.Fatalf("resync failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(skillPath, "checklist.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected a new version to replace the fetched skill with its placeholder, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package registrysync
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"skiller/internal/config"
+	"skiller/internal/fsutil"
+	"skiller/internal/scan"
+)
+
+type SkillChanges struct {
+	From    string   `json:"from,omitempty"`
+	To      string   `json:"to,omitempty"`
+	Added   []string `json:"added,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Stat    string   `json:"stat,omitempty"`
+}
+
+func (c SkillChanges) IsZero() bool {
+	return c.From == "" && c.To == "" && !c.HasSkills()
+}
+
+func (c SkillChanges) HasSkills() bool {
+	return len(c.Added) > 0 || len(c.Changed) > 0 || len(c.Removed) > 0
+}
+
+func (c SkillChanges) Range() string {
+	if c.To == "" {
+		return ""
+	}
+	return shortCommit(c.From) + ".." + shortCommit(c.To)
+}
+
+func (c SkillChanges) Status(path string) string {
+	for _, added := range c.Added {
+		if added == path {
+			return "new"
+		}
+	}
+	for _, changed := range c.Changed {
+		if changed == path {
+			return "changed"
+		}
+	}
+	return ""
+}
+
+func snapshotSkills(root string) map[string]string {
+	skills, err := scan.ScanRegistry(root)
+	if err != nil {
+		return nil
+	}
+
+	hashes := make(map[string]string, len(skills))
+	for _, skill := range skills {
+		relative, err := filepath.Rel(root, skill.Path)
+		if err != nil {
+			continue
+		}
+		hash, err := fsutil.HashDir(skill.Path)
+		if err != nil {
+			continue
+		}
+		hashes[filepath.ToSlash(relative)] = hash
+	}
+	return hashes
+}
+
+func diffSkills(before, after map[string]string) SkillChanges {
+	var changes SkillChanges
+	for path, hash := range after {
+		previous, ok := before[path]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, path)
+		case previous != hash:
+			changes.Changed = append(changes.Changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changes.Removed = append(changes.Removed, path)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Removed)
+	return changes
+}
+
+func diffStat(ctx context.Context, registry config.Registry, repoPath string, changes SkillChanges) string {
+	args := []string{"diff", "--stat", changes.From, changes.To, "--"}
+	for _, skills := range [][]string{changes.Added, changes.Changed, changes.Removed} {
+		for _, skill := range skills {
+			args = append(args, path.Join(registry.Subdir, skill))
+		}
+	}
+
+	output, err := gitOutput(ctx, repoPath, false, args...)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(output, "\n")
+}
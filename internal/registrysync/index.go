@@ -0,0 +1,317 @@
+package registrysync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"skiller/internal/config"
+	"skiller/internal/scan"
+)
+
+const (
+	catalogFileName = "catalog.json"
+	fetchedFileName = "fetched.json"
+)
+
+const fetchTimeout = 2 * time.Minute
+
+var indexMu sync.Mutex
+
+type Catalog struct {
+	Skills []CatalogSkill `json:"skills" toml:"skills"`
+}
+
+type CatalogSkill struct {
+	Name        string   `json:"name" toml:"name"`
+	Path        string   `json:"path,omitempty" toml:"path,omitempty"`
+	Description string   `json:"description,omitempty" toml:"description,omitempty"`
+	Version     string   `json:"version,omitempty" toml:"version,omitempty"`
+	Tags        []string `json:"tags,omitempty" toml:"tags,omitempty"`
+	URL         string   `json:"url" toml:"url"`
+	SHA256      string   `json:"sha256,omitempty" toml:"sha256,omitempty"`
+}
+
+func ParseCatalog(data []byte) (Catalog, error) {
+	var catalog Catalog
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return Catalog{}, fmt.Errorf("parse catalog: %w", err)
+		}
+	} else if _, err := toml.Decode(string(data), &catalog); err != nil {
+		return Catalog{}, fmt.Errorf("parse catalog: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for i := range catalog.Skills {
+		skill := &catalog.Skills[i]
+		skill.Name = strings.TrimSpace(skill.Name)
+		skill.URL = strings.TrimSpace(skill.URL)
+		skill.SHA256 = strings.ToLower(strings.TrimSpace(skill.SHA256))
+		if skill.Name == "" || strings.ContainsAny(skill.Name, `/\`) {
+			return Catalog{}, fmt.Errorf("catalog skill %d has an invalid name %q", i+1, skill.Name)
+		}
+		if skill.URL == "" {
+			return Catalog{}, fmt.Errorf("catalog skill %s has no url", skill.Name)
+		}
+
+		relative := strings.Trim(strings.TrimSpace(skill.Path), "/")
+		if relative == "" {
+			relative = skill.Name
+		}
+		relative = path.Clean(relative)
+		if _, err := archivePath(".", relative); err != nil || relative == "." || path.Base(relative) != skill.Name {
+			return Catalog{}, fmt.Errorf("catalog skill %s has an invalid path %q", skill.Name, skill.Path)
+		}
+		if seen[relative] {
+			return Catalog{}, fmt.Errorf("catalog lists %s twice", relative)
+		}
+		seen[relative] = true
+		skill.Path = relative
+	}
+	return catalog, nil
+}
+
+func syncIndex(ctx context.Context, registry config.Registry, repoPath string, report func(Phase)) (SyncResult, error) {
+	result := SyncResult{RepoPath: repoPath, PreviousCommit: previousCommit(registry), CommitsAhead: -1}
+	cacheDir := filepath.Dir(repoPath)
+
+	report(PhaseDownloading)
+	download, checksum, err := downloadTemp(ctx, registry.Source, cacheDir)
+	if err != nil {
+		return result, err
+	}
+	defer os.Remove(download)
+	result.Commit = checksum
+
+	data, err := os.ReadFile(download)
+	if err != nil {
+		return result, err
+	}
+	catalog, err := ParseCatalog(data)
+	if err != nil {
+		return result, &SyncError{Step: "catalog", Err: err}
+	}
+	for i := range catalog.Skills {
+		catalog.Skills[i].URL = resolveSource(registry.Source, catalog.Skills[i].URL)
+	}
+
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	fetched, err := loadFetched(cacheDir)
+	if err != nil {
+		return result, err
+	}
+
+	staged, err := os.MkdirTemp(cacheDir, "index-*")
+	if err != nil {
+		return result, err
+	}
+	defer os.RemoveAll(staged)
+
+	kept := map[string]string{}
+	for _, skill := range catalog.Skills {
+		dir := filepath.Join(staged, filepath.FromSlash(skill.Path))
+		previous := filepath.Join(repoPath, filepath.FromSlash(skill.Path))
+		if fetched[skill.Path] == fetchedKey(skill) && isDir(previous) {
+			if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+				return result, err
+			}
+			if err := os.Rename(previous, dir); err != nil {
+				return result, err
+			}
+			kept[skill.Path] = fetchedKey(skill)
+			continue
+		}
+		if err := writePlaceholder(dir, skill); err != nil {
+			return result, err
+		}
+	}
+
+	if err := writeJSON(filepath.Join(cacheDir, catalogFileName), catalog); err != nil {
+		return result, err
+	}
+	if err := writeJSON(filepath.Join(cacheDir, fetchedFileName), kept); err != nil {
+		return result, err
+	}
+	if err := replaceDir(staged, repoPath); err != nil {
+		return result, err
+	}
+
+	if result.PreviousCommit == checksum {
+		result.CommitsAhead = 0
+	}
+	return result, nil
+}
+
+func FetchSkill(registry config.Registry, skillPath string) error {
+	if registry.Type != config.RegistryTypeIndex {
+		return nil
+	}
+
+	repoPath, err := config.RegistryCachePath(registry)
+	if err != nil {
+		return err
+	}
+	cacheDir := filepath.Dir(repoPath)
+	relative, err := filepath.Rel(repoPath, skillPath)
+	if err != nil {
+		return err
+	}
+	relative = filepath.ToSlash(relative)
+
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	var catalog Catalog
+	if err := readJSON(filepath.Join(cacheDir, catalogFileName), &catalog); err != nil {
+		return fmt.Errorf("registry %s is not synced: %w", registry.DisplayName(), err)
+	}
+	var entry *CatalogSkill
+	for i := range catalog.Skills {
+		if catalog.Skills[i].Path == relative {
+			entry = &catalog.Skills[i]
+			break
+		}
+	}
+	if entry == nil {
+		return fmt.Errorf("%s is not listed in the index of %s", relative, registry.DisplayName())
+	}
+
+	fetched, err := loadFetched(cacheDir)
+	if err != nil {
+		return err
+	}
+	if fetched[relative] == fetchedKey(*entry) {
+		return nil
+	}
+	if Offline() {
+		return fmt.Errorf("fetch %s: %w", entry.Name, ErrOffline)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	archive, checksum, err := downloadTemp(ctx, entry.URL, cacheDir)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", entry.Name, err)
+	}
+	defer os.Remove(archive)
+	if entry.SHA256 != "" && checksum != entry.SHA256 {
+		return fmt.Errorf("fetch %s: checksum mismatch, the index lists %s but the archive is %s", entry.Name, entry.SHA256, checksum)
+	}
+
+	staged, err := os.MkdirTemp(cacheDir, "skill-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staged)
+
+	if err := extractArchive(archive, staged); err != nil {
+		return fmt.Errorf("extract %s: %w", entry.Name, err)
+	}
+	root, err := archiveSkillRoot(staged)
+	if err != nil {
+		return fmt.Errorf("extract %s: %w", entry.Name, err)
+	}
+	if err := replaceDir(root, skillPath); err != nil {
+		return err
+	}
+
+	fetched[relative] = fetchedKey(*entry)
+	return writeJSON(filepath.Join(cacheDir, fetchedFileName), fetched)
+}
+
+func archiveSkillRoot(dir string) (string, error) {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, scan.MarkerFileName)); err == nil {
+			return dir, nil
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return "", err
+		}
+		if len(entries) != 1 || !entries[0].IsDir() {
+			return "", errors.New("archive has no SKILL.md")
+		}
+		dir = filepath.Join(dir, entries[0].Name())
+	}
+}
+
+func writePlaceholder(dir string, skill CatalogSkill) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	var doc strings.Builder
+	doc.WriteString("---\n")
+	fmt.Fprintf(&doc, "name: %s\n", strconv.Quote(skill.Name))
+	if skill.Description != "" {
+		fmt.Fprintf(&doc, "description: %s\n", strconv.Quote(skill.Description))
+	}
+	if skill.Version != "" {
+		fmt.Fprintf(&doc, "version: %s\n", strconv.Quote(skill.Version))
+	}
+	if len(skill.Tags) > 0 {
+		quoted := make([]string, len(skill.Tags))
+		for i, tag := range skill.Tags {
+			quoted[i] = strconv.Quote(tag)
+		}
+		fmt.Fprintf(&doc, "tags: [%s]\n", strings.Join(quoted, ", "))
+	}
+	doc.WriteString("---\n\n")
+	fmt.Fprintf(&doc, "Listed in the registry index and not downloaded yet. Installing it fetches %s.\n", skill.URL)
+
+	return os.WriteFile(filepath.Join(dir, scan.MarkerFileName), []byte(doc.String()), 0o644)
+}
+
+func fetchedKey(skill CatalogSkill) string {
+	if skill.SHA256 != "" {
+		return skill.SHA256
+	}
+	return skill.URL + "#" + skill.Version
+}
+
+func loadFetched(cacheDir string) (map[string]string, error) {
+	fetched := map[string]string{}
+	err := readJSON(filepath.Join(cacheDir, fetchedFileName), &fetched)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if fetched == nil {
+		fetched = map[string]string{}
+	}
+	return fetched, err
+}
+
+func readJSON(path string, value any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+func writeJSON(path string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
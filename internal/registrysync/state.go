@@ -0,0 +1,181 @@
+package registrysync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"skiller/internal/config"
+)
+
+const stateFileName = "registries.json"
+
+var stateMu sync.Mutex
+
+type RegistryState struct {
+	Commit         string       `json:"commit,omitempty"`
+	PreviousCommit string       `json:"previous_commit,omitempty"`
+	CommitsAhead   int          `json:"commits_ahead"`
+	SyncedAt       time.Time    `json:"synced_at,omitzero"`
+	LastAttempt    time.Time    `json:"last_attempt,omitzero"`
+	LastError      string       `json:"last_error,omitempty"`
+	Changes        SkillChanges `json:"changes,omitzero"`
+}
+
+func (s RegistryState) Stale(interval time.Duration, now time.Time) bool {
+	return s.SyncedAt.IsZero() || now.Sub(s.SyncedAt) >= interval
+}
+
+func (s RegistryState) ShortCommit() string {
+	return shortCommit(s.Commit)
+}
+
+func (s RegistryState) Summary() string {
+	return describeSync(s.Commit, s.PreviousCommit, s.CommitsAhead)
+}
+
+func (s RegistryState) CachedAge(now time.Time) string {
+	if s.SyncedAt.IsZero() {
+		return ""
+	}
+	return FormatAge(now.Sub(s.SyncedAt))
+}
+
+func FormatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age/time.Minute))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age/time.Hour))
+	default:
+		return fmt.Sprintf("%dd ago", int(age/(24*time.Hour)))
+	}
+}
+
+type State struct {
+	Registries map[string]RegistryState `json:"registries"`
+}
+
+func StatePath() (string, error) {
+	root, err := config.StateRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, config.AppName, stateFileName), nil
+}
+
+func LoadState() (State, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	return loadState()
+}
+
+func updateState(registryID string, update func(*RegistryState)) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	entry := state.Registries[registryID]
+	update(&entry)
+	state.Registries[registryID] = entry
+	return saveState(state)
+}
+
+func forgetState(registryID string) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state, err := loadState()
+	if err != nil {
+		return err
+	}
+	if _, ok := state.Registries[registryID]; !ok {
+		return nil
+	}
+
+	delete(state.Registries, registryID)
+	return saveState(state)
+}
+
+func loadState() (State, error) {
+	state := State{Registries: map[string]RegistryState{}}
+
+	path, err := StatePath()
+	if err != nil {
+		return state, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return state, nil
+		}
+		return state, err
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{Registries: map[string]RegistryState{}}, err
+	}
+	if state.Registries == nil {
+		state.Registries = map[string]RegistryState{}
+	}
+	return state, nil
+}
+
+func saveState(state State) error {
+	path, err := StatePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+func describeSync(commit, previous string, ahead int) string {
+	if commit == "" {
+		return ""
+	}
+
+	summary := "synced " + shortCommit(commit)
+	switch {
+	case previous == "":
+	case ahead == 0:
+		summary += ", no new commits since last sync"
+	case ahead == 1:
+		summary += ", 1 commit ahead of last sync"
+	case ahead > 1:
+		summary += fmt.Sprintf(", %d commits ahead of last sync", ahead)
+	default:
+		summary += ", moved from " + shortCommit(previous)
+	}
+	return summary
+}
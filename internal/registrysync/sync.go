@@ -7,15 +7,58 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"skiller/internal/config"
 )
 
+const gitWaitDelay = 2 * time.Second
+
 type SyncResult struct {
-	RepoPath string
-	Output   string
+	RepoPath       string
+	Output         string
+	Commit         string
+	PreviousCommit string
+	CommitsAhead   int
+	Changes        SkillChanges
+}
+
+func (r SyncResult) ShortCommit() string {
+	return shortCommit(r.Commit)
+}
+
+func (r SyncResult) Summary() string {
+	return describeSync(r.Commit, r.PreviousCommit, r.CommitsAhead)
+}
+
+type Phase string
+
+const (
+	PhaseCloning     Phase = "cloning"
+	PhaseFetching    Phase = "fetching"
+	PhaseResolving   Phase = "resolving"
+	PhaseDownloading Phase = "downloading"
+	PhaseExtracting  Phase = "extracting"
+)
+
+var ErrOffline = errors.New("offline mode is on")
+
+var offline atomic.Bool
+
+func SetOffline(on bool) {
+	offline.Store(on)
+}
+
+func Offline() bool {
+	return offline.Load()
+}
+
+type SyncOptions struct {
+	Interactive bool
+	Progress    func(Phase)
 }
 
 type SyncError struct {
@@ -36,10 +79,49 @@ func (e *SyncError) Unwrap() error {
 }
 
 func SyncRegistry(registry config.Registry, interactive bool, timeout time.Duration) (SyncResult, error) {
+	ctx := context.Background()
+	cancel := func() {}
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	return SyncRegistryContext(ctx, registry, SyncOptions{Interactive: interactive})
+}
+
+func SyncRegistryContext(ctx context.Context, registry config.Registry, opts SyncOptions) (SyncResult, error) {
 	if !registry.IsRemote() {
 		return SyncResult{}, errors.New("registry is not remote")
 	}
+	if Offline() {
+		return SyncResult{}, ErrOffline
+	}
+
+	scanRoot, err := config.RegistryScanRoot(registry)
+	if err != nil {
+		return SyncResult{}, err
+	}
+	before := snapshotSkills(scanRoot)
 
+	result, err := syncRegistry(ctx, registry, opts)
+	if err != nil {
+		if stateErr := recordFailure(registry, err); stateErr != nil {
+			return result, errors.Join(err, stateErr)
+		}
+		return result, err
+	}
+
+	if result.PreviousCommit != "" && result.Commit != result.PreviousCommit {
+		result.Changes = diffSkills(before, snapshotSkills(scanRoot))
+		result.Changes.From, result.Changes.To = result.PreviousCommit, result.Commit
+		if registry.Type == config.RegistryTypeGit && result.Changes.HasSkills() {
+			result.Changes.Stat = diffStat(ctx, registry, result.RepoPath, result.Changes)
+		}
+	}
+	return result, recordSync(registry, result)
+}
+
+func syncRegistry(ctx context.Context, registry config.Registry, opts SyncOptions) (SyncResult, error) {
 	repoPath, err := config.RegistryCachePath(registry)
 	if err != nil {
 		return SyncResult{}, err
@@ -49,40 +131,119 @@ func SyncRegistry(registry config.Registry, interactive bool, timeout time.Durat
 		return SyncResult{}, err
 	}
 
-	ctx := context.Background()
-	cancel := func() {}
-	if timeout > 0 {
-		ctx, cancel = context.WithTimeout(ctx, timeout)
+	report := func(phase Phase) {
+		if opts.Progress != nil {
+			opts.Progress(phase)
+		}
 	}
-	defer cancel()
 
-	if !isGitRepo(repoPath) {
-		if err := cloneRepo(ctx, registry, repoPath, interactive); err != nil {
-			return SyncResult{RepoPath: repoPath}, err
+	switch registry.Type {
+	case config.RegistryTypeArchive:
+		return syncArchive(ctx, registry, repoPath, report)
+	case config.RegistryTypeIndex:
+		return syncIndex(ctx, registry, repoPath, report)
+	}
+
+	result := SyncResult{RepoPath: repoPath, CommitsAhead: -1}
+	if isGitRepo(repoPath) {
+		if previous, err := headCommit(ctx, repoPath); err == nil {
+			result.PreviousCommit = previous
 		}
-		return SyncResult{RepoPath: repoPath}, nil
+	}
+	if result.PreviousCommit == "" {
+		result.PreviousCommit = previousCommit(registry)
+	}
+
+	if err := syncRepo(ctx, registry, repoPath, opts.Interactive, report); err != nil {
+		return result, err
+	}
+
+	report(PhaseResolving)
+	commit, err := headCommit(ctx, repoPath)
+	if err != nil {
+		return result, err
+	}
+	result.Commit = commit
+
+	if IsCommitRef(registry.Ref) && !strings.EqualFold(commit, registry.Ref) {
+		return result, &SyncError{Step: "checkout", Err: fmt.Errorf("checked out %s instead of pinned commit %s", shortCommit(commit), shortCommit(registry.Ref))}
+	}
+
+	switch {
+	case result.PreviousCommit == "":
+	case result.PreviousCommit == commit:
+		result.CommitsAhead = 0
+	default:
+		result.CommitsAhead = countCommits(ctx, registry, repoPath, result.PreviousCommit, commit)
+	}
+
+	return result, nil
+}
+
+func recordSync(registry config.Registry, result SyncResult) error {
+	now := time.Now().UTC().Truncate(time.Second)
+	return updateState(registry.ID, func(entry *RegistryState) {
+		entry.Commit = result.Commit
+		entry.PreviousCommit = result.PreviousCommit
+		entry.CommitsAhead = result.CommitsAhead
+		entry.SyncedAt = now
+		entry.LastAttempt = now
+		entry.LastError = ""
+		if result.Commit != result.PreviousCommit {
+			entry.Changes = result.Changes
+		}
+	})
+}
+
+func recordFailure(registry config.Registry, err error) error {
+	return updateState(registry.ID, func(entry *RegistryState) {
+		entry.LastAttempt = time.Now().UTC().Truncate(time.Second)
+		entry.LastError = err.Error()
+	})
+}
+
+func previousCommit(registry config.Registry) string {
+	state, err := LoadState()
+	if err != nil {
+		return ""
+	}
+	return state.Registries[registry.ID].Commit
+}
+
+func syncRepo(ctx context.Context, registry config.Registry, repoPath string, interactive bool, report func(Phase)) error {
+	if !isGitRepo(repoPath) {
+		report(PhaseCloning)
+		return cloneRepo(ctx, registry, repoPath, interactive)
 	}
 
 	originURL, err := gitOutput(ctx, repoPath, false, "config", "--get", "remote.origin.url")
 	if err != nil {
-		return SyncResult{RepoPath: repoPath}, &SyncError{Step: "origin-url", Output: originURL, Err: err}
+		return &SyncError{Step: "origin-url", Output: originURL, Err: err}
 	}
 
 	if strings.TrimSpace(originURL) != strings.TrimSpace(registry.Source) {
 		if err := os.RemoveAll(repoPath); err != nil {
-			return SyncResult{RepoPath: repoPath}, err
+			return err
 		}
-		if err := cloneRepo(ctx, registry, repoPath, interactive); err != nil {
-			return SyncResult{RepoPath: repoPath}, err
-		}
-		return SyncResult{RepoPath: repoPath}, nil
+		report(PhaseCloning)
+		return cloneRepo(ctx, registry, repoPath, interactive)
 	}
 
-	if err := fetchAndReset(ctx, registry, repoPath, interactive); err != nil {
-		return SyncResult{RepoPath: repoPath}, err
-	}
+	report(PhaseFetching)
+	return fetchAndReset(ctx, registry, repoPath, interactive)
+}
 
-	return SyncResult{RepoPath: repoPath}, nil
+func IsCommitRef(ref string) bool {
+	trimmed := strings.TrimSpace(ref)
+	if len(trimmed) != 40 && len(trimmed) != 64 {
+		return false
+	}
+	for _, r := range trimmed {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
 }
 
 func RemoveRegistryCache(registry config.Registry) error {
@@ -91,6 +252,10 @@ func RemoveRegistryCache(registry config.Registry) error {
 		return err
 	}
 
+	if err := forgetState(registry.ID); err != nil {
+		return err
+	}
+
 	cacheDir := filepath.Dir(repoPath)
 	if _, err := os.Stat(cacheDir); errors.Is(err, os.ErrNotExist) {
 		return nil
@@ -101,6 +266,22 @@ func RemoveRegistryCache(registry config.Registry) error {
 	return os.RemoveAll(cacheDir)
 }
 
+func ResolveCommit(dir string) (string, error) {
+	return headCommit(context.Background(), dir)
+}
+
+func RegistryCommit(registry config.Registry, scanRoot string) (string, error) {
+	switch registry.Type {
+	case config.RegistryTypeArchive, config.RegistryTypeIndex:
+		if commit := previousCommit(registry); commit != "" {
+			return commit, nil
+		}
+		return "", fmt.Errorf("registry %s is not synced", registry.DisplayName())
+	default:
+		return ResolveCommit(scanRoot)
+	}
+}
+
 func IsAuthError(err error) bool {
 	if err == nil {
 		return false
@@ -127,7 +308,14 @@ func IsAuthError(err error) bool {
 }
 
 func cloneRepo(ctx context.Context, registry config.Registry, repoPath string, interactive bool) error {
+	if IsCommitRef(registry.Ref) {
+		return cloneCommit(ctx, registry, repoPath, interactive)
+	}
+
 	args := []string{"clone", "--depth=1"}
+	if isSparse(registry) {
+		args = append(args, "--filter=blob:none", "--no-checkout")
+	}
 	if strings.TrimSpace(registry.Ref) != "" {
 		args = append(args, "--branch", registry.Ref)
 	}
@@ -138,11 +326,72 @@ func cloneRepo(ctx context.Context, registry config.Registry, repoPath string, i
 		return &SyncError{Step: "clone", Output: cloneOutput, Err: err}
 	}
 
+	if !isSparse(registry) {
+		return nil
+	}
+	if err := sparseCheckout(ctx, registry, repoPath); err != nil {
+		_ = os.RemoveAll(repoPath)
+		return err
+	}
+	checkoutOutput, err := gitOutput(ctx, repoPath, interactive, "checkout")
+	if err != nil {
+		_ = os.RemoveAll(repoPath)
+		return &SyncError{Step: "checkout", Output: checkoutOutput, Err: err}
+	}
+	return nil
+}
+
+func isSparse(registry config.Registry) bool {
+	return strings.TrimSpace(registry.Subdir) != ""
+}
+
+func sparseCheckout(ctx context.Context, registry config.Registry, repoPath string) error {
+	args := []string{"sparse-checkout", "disable"}
+	if isSparse(registry) {
+		args = []string{"sparse-checkout", "set", "--cone", registry.Subdir}
+	}
+	output, err := gitOutput(ctx, repoPath, false, args...)
+	if err != nil {
+		return &SyncError{Step: "sparse-checkout", Output: output, Err: err}
+	}
+	return nil
+}
+
+func cloneCommit(ctx context.Context, registry config.Registry, repoPath string, interactive bool) error {
+	if err := os.MkdirAll(repoPath, 0o755); err != nil {
+		return err
+	}
+
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", registry.Source},
+	}
+	if isSparse(registry) {
+		steps = append(steps,
+			[]string{"config", "remote.origin.promisor", "true"},
+			[]string{"config", "remote.origin.partialclonefilter", "blob:none"},
+		)
+	}
+	for _, args := range steps {
+		if output, err := gitOutput(ctx, repoPath, false, args...); err != nil {
+			_ = os.RemoveAll(repoPath)
+			return &SyncError{Step: "clone", Output: output, Err: err}
+		}
+	}
+
+	if err := fetchAndReset(ctx, registry, repoPath, interactive); err != nil {
+		_ = os.RemoveAll(repoPath)
+		return err
+	}
 	return nil
 }
 
 func fetchAndReset(ctx context.Context, registry config.Registry, repoPath string, interactive bool) error {
-	fetchArgs := []string{"fetch", "--depth=1", "origin"}
+	fetchArgs := []string{"fetch", "--depth=1"}
+	if isSparse(registry) {
+		fetchArgs = append(fetchArgs, "--filter=blob:none")
+	}
+	fetchArgs = append(fetchArgs, "origin")
 	if strings.TrimSpace(registry.Ref) != "" {
 		fetchArgs = append(fetchArgs, registry.Ref)
 	}
@@ -152,6 +401,10 @@ func fetchAndReset(ctx context.Context, registry config.Registry, repoPath strin
 		return &SyncError{Step: "fetch", Output: fetchOutput, Err: err}
 	}
 
+	if err := sparseCheckout(ctx, registry, repoPath); err != nil {
+		return err
+	}
+
 	resetOutput, err := gitOutput(ctx, repoPath, interactive, "reset", "--hard", "FETCH_HEAD")
 	if err != nil {
 		return &SyncError{Step: "reset", Output: resetOutput, Err: err}
@@ -161,12 +414,50 @@ func fetchAndReset(ctx context.Context, registry config.Registry, repoPath strin
 	return nil
 }
 
+func headCommit(ctx context.Context, repoPath string) (string, error) {
+	output, err := gitOutput(ctx, repoPath, false, "rev-parse", "HEAD")
+	if err != nil {
+		return "", &SyncError{Step: "rev-parse", Output: output, Err: err}
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// countCommits deepens the shallow cache a few times until previous is
+// reachable; -1 means the distance could not be determined.
+func countCommits(ctx context.Context, registry config.Registry, repoPath, previous, current string) int {
+	target := "HEAD"
+	if strings.TrimSpace(registry.Ref) != "" {
+		target = registry.Ref
+	}
+
+	for attempt := 0; attempt < 4; attempt++ {
+		if _, err := gitOutput(ctx, repoPath, false, "merge-base", "--is-ancestor", previous, current); err == nil {
+			output, err := gitOutput(ctx, repoPath, false, "rev-list", "--count", previous+".."+current)
+			if err != nil {
+				return -1
+			}
+			count, err := strconv.Atoi(strings.TrimSpace(output))
+			if err != nil {
+				return -1
+			}
+			return count
+		}
+
+		if _, err := gitOutput(ctx, repoPath, false, "fetch", "--deepen=50", "origin", target); err != nil {
+			return -1
+		}
+	}
+
+	return -1
+}
+
 func gitOutput(ctx context.Context, dir string, interactive bool, args ...string) (string, error) {
 	cmd := exec.CommandContext(ctx, "git", args...)
 	if dir != "" {
 		cmd.Dir = dir
 	}
 
+	cmd.WaitDelay = gitWaitDelay
 	cmd.Env = append([]string{}, os.Environ()...)
 	if !interactive {
 		cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0")
@@ -177,13 +468,23 @@ func gitOutput(ctx context.Context, dir string, interactive bool, args ...string
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		if err := cmd.Run(); err != nil {
-			return "", err
+			return "", contextError(ctx, err)
 		}
 		return "", nil
 	}
 
 	output, err := cmd.CombinedOutput()
-	return string(output), err
+	if err != nil {
+		return string(output), contextError(ctx, err)
+	}
+	return string(output), nil
+}
+
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%w (%v)", ctxErr, err)
+	}
+	return err
 }
 
 func isGitRepo(path string) bool {
@@ -1,10 +1,17 @@
 package registrysync
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"skiller/internal/config"
 )
@@ -21,6 +28,7 @@ func TestIsAuthError(t *testing.T) {
 
 func TestRemoveRegistryCache(t *testing.T) {
 	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
 
 	registry := config.Registry{Type: config.RegistryTypeGit, Source: "https://github.com/acme/skills.git"}
 	repoPath, err := config.RegistryCachePath(registry)
@@ -44,3 +52,352 @@ func TestRemoveRegistryCache(t *testing.T) {
 		t.Fatalf("expected cache dir to be removed")
 	}
 }
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=skiller", "GIT_AUTHOR_EMAIL=skiller@example.com",
+		"GIT_COMMITTER_NAME=skiller", "GIT_COMMITTER_EMAIL=skiller@example.com",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v (%s)", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func commitFile(t *testing.T, repo, name, content string) string {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(repo, name)), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "--quiet", "-m", "update "+name)
+	return runGit(t, repo, "rev-parse", "HEAD")
+}
+
+func newUpstream(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	repo := t.TempDir()
+	runGit(t, repo, "init", "--quiet", "--initial-branch=main")
+	return repo
+}
+
+func TestSyncRegistryReportsResolvedCommits(t *testing.T) {
+	upstream := newUpstream(t)
+	first := commitFile(t, upstream, "alpha/SKILL.md", "# alpha")
+
+	registry, err := config.NormalizeRegistry(config.Registry{Type: config.RegistryTypeGit, Source: "file://" + upstream, Ref: "main"})
+	if err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+
+	result, err := SyncRegistry(registry, false, time.Minute)
+	if err != nil {
+		t.Fatalf("initial sync failed: %v", err)
+	}
+	if result.Commit != first || result.PreviousCommit != "" || result.CommitsAhead != -1 {
+		t.Fatalf("unexpected initial result: %#v", result)
+	}
+
+	commitFile(t, upstream, "beta/SKILL.md", "# beta")
+	commitFile(t, upstream, "gamma/SKILL.md", "# gamma")
+	third := commitFile(t, upstream, "alpha/SKILL.md", "# alpha v2")
+
+	result, err = SyncRegistry(registry, false, time.Minute)
+	if err != nil {
+		t.Fatalf("second sync failed: %v", err)
+	}
+	if result.Commit != third || result.PreviousCommit != first || result.CommitsAhead != 3 {
+		t.Fatalf("unexpected second result: %#v", result)
+	}
+
+	state, err := LoadState()
+	if err != nil {
+		t.Fatalf("load state failed: %v", err)
+	}
+	entry := state.Registries[registry.ID]
+	if entry.Commit != third || entry.PreviousCommit != first || entry.CommitsAhead != 3 || entry.SyncedAt.IsZero() {
+		t.Fatalf("unexpected persisted state: %#v", entry)
+	}
+}
+
+func TestSyncRegistryPinsCommit(t *testing.T) {
+	upstream := newUpstream(t)
+	first := commitFile(t, upstream, "alpha/SKILL.md", "# alpha")
+	commitFile(t, upstream, "alpha/SKILL.md", "# alpha v2")
+
+	registry, err := config.NormalizeRegistry(config.Registry{Type: config.RegistryTypeGit, Source: "file://" + upstream, Ref: first})
+	if err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+
+	result, err := SyncRegistry(registry, false, time.Minute)
+	if err != nil {
+		t.Fatalf("pinned sync failed: %v", err)
+	}
+	if result.Commit != first {
+		t.Fatalf("expected pinned commit %s, got %s", first, result.Commit)
+	}
+
+	content, err := os.ReadFile(filepath.Join(result.RepoPath, "alpha", "SKILL.md"))
+	if err != nil || string(content) != "# alpha" {
+		t.Fatalf("expected pinned content, got %q (%v)", content, err)
+	}
+
+	if _, err := SyncRegistry(registry, false, time.Minute); err != nil {
+		t.Fatalf("pinned resync failed: %v", err)
+	}
+}
+
+func TestSyncRegistrySparseChecksOutSubdir(t *testing.T) {
+	upstream := newUpstream(t)
+	commitFile(t, upstream, "skills/alpha/SKILL.md", "# alpha")
+	pinned := commitFile(t, upstream, "vendor/huge.txt", "unrelated")
+
+	var cfg config.Config
+	if err := cfg.AddRegistry("file://" + upstream + "#main:skills"); err != nil {
+		t.Fatalf("add registry failed: %v", err)
+	}
+	registry := cfg.Registries[0]
+	if registry.Ref != "main" || registry.Subdir != "skills" {
+		t.Fatalf("expected ref and subdir to be parsed, got %#v", registry)
+	}
+
+	result, err := SyncRegistry(registry, false, time.Minute)
+	if err != nil {
+		t.Fatalf("sparse sync failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(result.RepoPath, "skills", "alpha", "SKILL.md")); err != nil {
+		t.Fatalf("expected subdir to be checked out: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(result.RepoPath, "vendor")); !os.IsNotExist(err) {
+		t.Fatalf("expected content outside the subdir to stay out of the checkout, got %v", err)
+	}
+
+	commitFile(t, upstream, "skills/beta/SKILL.md", "# beta")
+	commitFile(t, upstream, "vendor/more.txt", "unrelated")
+	if result, err = SyncRegistry(registry, false, time.Minute); err != nil {
+		t.Fatalf("sparse resync failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(result.RepoPath, "skills", "beta", "SKILL.md")); err != nil {
+		t.Fatalf("expected new subdir content after fetch: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(result.RepoPath, "vendor")); !os.IsNotExist(err) {
+		t.Fatalf("expected fetch to keep the checkout sparse, got %v", err)
+	}
+
+	pinnedRegistry, err := config.NormalizeRegistry(config.Registry{Type: config.RegistryTypeGit, Source: "file://" + upstream, Ref: pinned, Subdir: "skills"})
+	if err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+	if result, err = SyncRegistry(pinnedRegistry, false, time.Minute); err != nil {
+		t.Fatalf("pinned sparse sync failed: %v", err)
+	}
+	if result.Commit != pinned {
+		t.Fatalf("expected pinned commit %s, got %s", pinned, result.Commit)
+	}
+	if _, err := os.Stat(filepath.Join(result.RepoPath, "vendor")); !os.IsNotExist(err) {
+		t.Fatalf("expected pinned checkout to be sparse, got %v", err)
+	}
+}
+
+func TestSyncRegistryFollowsSubdirChangesInAnExistingCache(t *testing.T) {
+	upstream := newUpstream(t)
+	commitFile(t, upstream, "skills/alpha/SKILL.md", "# alpha")
+	commitFile(t, upstream, "vendor/huge.txt", "unrelated")
+
+	registry, err := config.NormalizeRegistry(config.Registry{Type: config.RegistryTypeGit, Source: "file://" + upstream, Ref: "main"})
+	if err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+	result, err := SyncRegistry(registry, false, time.Minute)
+	if err != nil {
+		t.Fatalf("full sync failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(result.RepoPath, "vendor", "huge.txt")); err != nil {
+		t.Fatalf("expected a full checkout: %v", err)
+	}
+
+	registry.Subdir = "skills"
+	if result, err = SyncRegistry(registry, false, time.Minute); err != nil {
+		t.Fatalf("sparse resync failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(result.RepoPath, "vendor")); !os.IsNotExist(err) {
+		t.Fatalf("expected the existing cache to become sparse, got %v", err)
+	}
+
+	registry.Subdir = ""
+	if result, err = SyncRegistry(registry, false, time.Minute); err != nil {
+		t.Fatalf("full resync failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(result.RepoPath, "vendor", "huge.txt")); err != nil {
+		t.Fatalf("expected the full checkout back: %v", err)
+	}
+}
+
+func TestSyncRegistryContextReportsPhasesAndCancels(t *testing.T) {
+	upstream := newUpstream(t)
+	commitFile(t, upstream, "alpha/SKILL.md", "# alpha")
+
+	registry, err := config.NormalizeRegistry(config.Registry{Type: config.RegistryTypeGit, Source: "file://" + upstream, Ref: "main"})
+	if err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+
+	var phases []Phase
+	opts := SyncOptions{Progress: func(phase Phase) { phases = append(phases, phase) }}
+	if _, err := SyncRegistryContext(context.Background(), registry, opts); err != nil {
+		t.Fatalf("initial sync failed: %v", err)
+	}
+	if _, err := SyncRegistryContext(context.Background(), registry, opts); err != nil {
+		t.Fatalf("second sync failed: %v", err)
+	}
+
+	want := []Phase{PhaseCloning, PhaseResolving, PhaseFetching, PhaseResolving}
+	if !reflect.DeepEqual(phases, want) {
+		t.Fatalf("expected phases %v, got %v", want, phases)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := SyncRegistryContext(ctx, registry, SyncOptions{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancelled sync, got %v", err)
+	}
+}
+
+func TestSyncRecordsFailuresAndHonoursOffline(t *testing.T) {
+	upstream := newUpstream(t)
+	commitFile(t, upstream, "alpha/SKILL.md", "# alpha")
+
+	registry, err := config.NormalizeRegistry(config.Registry{Type: config.RegistryTypeGit, Source: "file://" + upstream})
+	if err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+	if _, err := SyncRegistry(registry, false, time.Minute); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+
+	if err := os.RemoveAll(upstream); err != nil {
+		t.Fatalf("remove upstream failed: %v", err)
+	}
+	if _, err := SyncRegistry(registry, false, time.Minute); err == nil {
+		t.Fatalf("expected sync of a missing upstream to fail")
+	}
+
+	state, err := LoadState()
+	if err != nil {
+		t.Fatalf("load state failed: %v", err)
+	}
+	entry := state.Registries[registry.ID]
+	if entry.Commit == "" || entry.SyncedAt.IsZero() || entry.LastAttempt.Before(entry.SyncedAt) || entry.LastError == "" {
+		t.Fatalf("expected the failure next to the last success, got %#v", entry)
+	}
+	if age := entry.CachedAge(entry.SyncedAt.Add(73 * time.Hour)); age != "3d ago" {
+		t.Fatalf("expected cache age 3d ago, got %q", age)
+	}
+
+	SetOffline(true)
+	t.Cleanup(func() { SetOffline(false) })
+	if _, err := SyncRegistry(registry, false, time.Minute); !errors.Is(err, ErrOffline) {
+		t.Fatalf("expected offline sync to be refused, got %v", err)
+	}
+}
+
+func TestStateLeavesOutUnsetTimes(t *testing.T) {
+	data, err := json.Marshal(RegistryState{LastError: "boom"})
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if strings.Contains(string(data), "synced_at") || strings.Contains(string(data), "last_attempt") {
+		t.Fatalf("expected unset times to be left out, got %s", data)
+	}
+}
+
+func TestSyncRecordsSkillChanges(t *testing.T) {
+	upstream := newUpstream(t)
+	commitFile(t, upstream, "alpha/SKILL.md", "# alpha")
+	commitFile(t, upstream, "gamma/SKILL.md", "# gamma")
+
+	registry, err := config.NormalizeRegistry(config.Registry{Type: config.RegistryTypeGit, Source: "file://" + upstream})
+	if err != nil {
+		t.Fatalf("normalize failed: %v", err)
+	}
+	result, err := SyncRegistry(registry, false, time.Minute)
+	if err != nil {
+		t.Fatalf("initial sync failed: %v", err)
+	}
+	if !result.Changes.IsZero() {
+		t.Fatalf("expected no changes on a first sync, got %#v", result.Changes)
+	}
+
+	commitFile(t, upstream, "alpha/SKILL.md", "# alpha v2")
+	commitFile(t, upstream, "tools/beta/SKILL.md", "# beta")
+	runGit(t, upstream, "rm", "-r", "--quiet", "gamma")
+	runGit(t, upstream, "commit", "--quiet", "-m", "drop gamma")
+
+	commitFile(t, upstream, "README.md", "not a skill")
+
+	previous := result.Commit
+	if result, err = SyncRegistry(registry, false, time.Minute); err != nil {
+		t.Fatalf("second sync failed: %v", err)
+	}
+	stat := result.Changes.Stat
+	for _, want := range []string{"alpha/SKILL.md", "tools/beta/SKILL.md", "gamma/SKILL.md", "3 files changed"} {
+		if !strings.Contains(stat, want) {
+			t.Fatalf("expected %q in the diff stat, got:\n%s", want, stat)
+		}
+	}
+	if strings.Contains(stat, "README.md") {
+		t.Fatalf("expected the diff stat to be limited to skill folders, got:\n%s", stat)
+	}
+	want := SkillChanges{
+		From:    previous,
+		To:      result.Commit,
+		Added:   []string{"tools/beta"},
+		Changed: []string{"alpha"},
+		Removed: []string{"gamma"},
+		Stat:    stat,
+	}
+	if !reflect.DeepEqual(result.Changes, want) {
+		t.Fatalf("expected %#v, got %#v", want, result.Changes)
+	}
+
+	if _, err := SyncRegistry(registry, false, time.Minute); err != nil {
+		t.Fatalf("third sync failed: %v", err)
+	}
+	state, err := LoadState()
+	if err != nil {
+		t.Fatalf("load state failed: %v", err)
+	}
+	if changes := state.Registries[registry.ID].Changes; !reflect.DeepEqual(changes, want) {
+		t.Fatalf("expected an unchanged sync to keep the last changes, got %#v", changes)
+	}
+	if status := state.Registries[registry.ID].Changes.Status("tools/beta"); status != "new" {
+		t.Fatalf("expected tools/beta to be new, got %q", status)
+	}
+}
+
+func TestIsCommitRef(t *testing.T) {
+	if !IsCommitRef("0123456789abcdef0123456789abcdef01234567") {
+		t.Fatalf("expected full sha to be a commit ref")
+	}
+	if IsCommitRef("main") || IsCommitRef("0123456") {
+		t.Fatalf("expected branch names and short shas to not be commit refs")
+	}
+}
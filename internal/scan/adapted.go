@@ -0,0 +1,95 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sectionBeginPrefix = "<!-- skiller:begin "
+	sectionEndPrefix   = "<!-- skiller:end "
+	sectionSuffix      = " -->"
+)
+
+var adaptedExtensions = map[string]bool{".md": true, ".mdc": true}
+
+func SectionBegin(name string) string {
+	return sectionBeginPrefix + name + sectionSuffix
+}
+
+func SectionEnd(name string) string {
+	return sectionEndPrefix + name + sectionSuffix
+}
+
+func SectionNames(data []byte) []string {
+	var names []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, sectionBeginPrefix) && strings.HasSuffix(line, sectionSuffix) {
+			name := strings.TrimSuffix(strings.TrimPrefix(line, sectionBeginPrefix), sectionSuffix)
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+func AdaptedFile(path, name string) bool {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) == name
+}
+
+func scanAdapted(path, parent string) ([]Skill, error) {
+	if !adaptedExtensions[strings.ToLower(filepath.Ext(path))] {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var skills []Skill
+	for _, name := range SectionNames(data) {
+		skill := Skill{Name: name, Path: path, Parent: parent, Adapted: true}
+		if AdaptedFile(path, name) {
+			metadata, _, err := ParseFrontmatter(data)
+			if err != nil {
+				skill.MetadataErr = fmt.Errorf("%s: %w", path, err)
+			}
+			skill.Metadata = metadata
+		} else {
+			skill.Path = path + "#" + name
+		}
+		skills = append(skills, skill)
+	}
+	return skills, nil
+}
+
+func FindAdapted(harnessPath, name string) (Skill, bool, error) {
+	entries, err := os.ReadDir(harnessPath)
+	if err != nil {
+		return Skill{}, false, err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		skills, err := scanAdapted(filepath.Join(harnessPath, entry.Name()), harnessPath)
+		if err != nil {
+			return Skill{}, false, err
+		}
+		for _, skill := range skills {
+			if skill.Name == name {
+				return skill, true, nil
+			}
+		}
+	}
+	return Skill{}, false, nil
+}
@@ -0,0 +1,361 @@
+package scan
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Metadata struct {
+	Name         string
+	Description  string
+	Version      string
+	License      string
+	Tags         []string
+	AllowedTools []string
+}
+
+var errUnterminatedFrontmatter = errors.New("frontmatter is missing its closing ---")
+
+func ParseFrontmatter(data []byte) (Metadata, string, error) {
+	text := strings.ReplaceAll(string(bytes.TrimPrefix(data, []byte("\ufeff"))), "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") && text != "---" {
+		return Metadata{}, text, nil
+	}
+
+	lines := strings.Split(text, "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], " \t") == "---" || strings.TrimRight(lines[i], " \t") == "..." {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return Metadata{}, text, errUnterminatedFrontmatter
+	}
+
+	body := strings.TrimLeft(strings.Join(lines[end+1:], "\n"), "\n")
+	values, err := parseFrontmatterLines(lines[1:end])
+	if err != nil {
+		return Metadata{}, body, err
+	}
+
+	meta := Metadata{
+		Name:         values["name"].scalar(),
+		Description:  values["description"].scalar(),
+		Version:      values["version"].scalar(),
+		License:      values["license"].scalar(),
+		Tags:         values["tags"].list(),
+		AllowedTools: values["allowed-tools"].list(),
+	}
+	return meta, body, nil
+}
+
+type frontmatterValue struct {
+	text  string
+	items []string
+	isSeq bool
+}
+
+func (v frontmatterValue) scalar() string {
+	if v.isSeq {
+		return strings.Join(v.items, ", ")
+	}
+	return v.text
+}
+
+func (v frontmatterValue) list() []string {
+	if v.isSeq {
+		return v.items
+	}
+	return splitList(v.text)
+}
+
+func parseFrontmatterLines(lines []string) (map[string]frontmatterValue, error) {
+	values := map[string]frontmatterValue{}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if isBlankOrComment(line) {
+			continue
+		}
+		if indentOf(line) > 0 {
+			return nil, fmt.Errorf("frontmatter line %d: unexpected indentation", i+2)
+		}
+
+		key, rest, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("frontmatter line %d: expected \"key: value\"", i+2)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimSpace(rest)
+
+		block, next := collectIndented(lines, i+1)
+		i = next - 1
+
+		var value frontmatterValue
+		var err error
+		switch {
+		case rest == "" && startsSequence(block):
+			value, err = parseBlockSequence(block)
+		case rest == "":
+			value = frontmatterValue{text: foldPlain(block)}
+		case strings.HasPrefix(rest, "|") || strings.HasPrefix(rest, ">"):
+			value = frontmatterValue{text: blockScalar(rest, block)}
+		case strings.HasPrefix(rest, "["):
+			value, err = parseFlowSequence(rest, block)
+		default:
+			var text string
+			text, err = parseScalar(joinContinuation(rest, block))
+			value = frontmatterValue{text: text}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("frontmatter key %s: %w", key, err)
+		}
+
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+func collectIndented(lines []string, start int) ([]string, int) {
+	end := start
+	for end < len(lines) {
+		line := lines[end]
+		if strings.TrimSpace(line) != "" && indentOf(line) == 0 && !strings.HasPrefix(line, "- ") {
+			break
+		}
+		end++
+	}
+
+	block := lines[start:end]
+	for len(block) > 0 && strings.TrimSpace(block[len(block)-1]) == "" {
+		block = block[:len(block)-1]
+	}
+	return block, end
+}
+
+func parseBlockSequence(block []string) (frontmatterValue, error) {
+	value := frontmatterValue{isSeq: true, items: []string{}}
+	for _, line := range block {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "-") {
+			if len(value.items) == 0 {
+				return frontmatterValue{}, errors.New("expected a \"- item\" sequence")
+			}
+			value.items[len(value.items)-1] += " " + trimmed
+			continue
+		}
+
+		item, err := parseScalar(strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))
+		if err != nil {
+			return frontmatterValue{}, err
+		}
+		value.items = append(value.items, item)
+	}
+	return value, nil
+}
+
+func parseFlowSequence(rest string, block []string) (frontmatterValue, error) {
+	text := joinContinuation(rest, block)
+	if idx := strings.LastIndex(text, "]"); idx >= 0 && strings.HasPrefix(strings.TrimSpace(text[idx+1:]), "#") {
+		text = text[:idx+1]
+	}
+	if !strings.HasSuffix(text, "]") {
+		return frontmatterValue{}, errors.New("unterminated [ sequence")
+	}
+
+	value := frontmatterValue{isSeq: true, items: []string{}}
+	inner := strings.TrimSpace(text[1 : len(text)-1])
+	if inner == "" {
+		return value, nil
+	}
+
+	for _, part := range splitTopLevel(inner) {
+		item, err := parseScalar(strings.TrimSpace(part))
+		if err != nil {
+			return frontmatterValue{}, err
+		}
+		if item != "" {
+			value.items = append(value.items, item)
+		}
+	}
+	return value, nil
+}
+
+func parseScalar(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return "", nil
+	}
+
+	switch trimmed[0] {
+	case '"':
+		end := closingQuote(trimmed, '"')
+		if end < 0 {
+			return "", errors.New("unterminated double-quoted string")
+		}
+		unquoted, err := strconv.Unquote(trimmed[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid double-quoted string: %w", err)
+		}
+		return unquoted, nil
+	case '\'':
+		end := closingQuote(trimmed, '\'')
+		if end < 0 {
+			return "", errors.New("unterminated single-quoted string")
+		}
+		return strings.ReplaceAll(trimmed[1:end], "''", "'"), nil
+	}
+
+	if idx := strings.Index(trimmed, " #"); idx >= 0 {
+		trimmed = strings.TrimSpace(trimmed[:idx])
+	}
+	return trimmed, nil
+}
+
+func closingQuote(text string, quote byte) int {
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case quote == '\'' && text[i] == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func blockScalar(indicator string, block []string) string {
+	indent := -1
+	for _, line := range block {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if indent < 0 || indentOf(line) < indent {
+			indent = indentOf(line)
+		}
+	}
+
+	stripped := make([]string, 0, len(block))
+	for _, line := range block {
+		if len(line) >= indent && indent >= 0 {
+			stripped = append(stripped, line[indent:])
+		} else {
+			stripped = append(stripped, strings.TrimSpace(line))
+		}
+	}
+
+	var text string
+	if strings.HasPrefix(indicator, ">") {
+		text = foldLines(stripped)
+	} else {
+		text = strings.Join(stripped, "\n")
+	}
+
+	return strings.TrimRight(text, "\n")
+}
+
+func foldPlain(block []string) string {
+	parts := make([]string, 0, len(block))
+	for _, line := range block {
+		parts = append(parts, strings.TrimSpace(line))
+	}
+	return foldLines(parts)
+}
+
+func foldLines(lines []string) string {
+	var out strings.Builder
+	pendingBreak := false
+	for _, line := range lines {
+		if line == "" {
+			out.WriteString("\n")
+			pendingBreak = false
+			continue
+		}
+		if pendingBreak {
+			out.WriteString(" ")
+		}
+		out.WriteString(line)
+		pendingBreak = true
+	}
+	return strings.TrimSpace(out.String())
+}
+
+func joinContinuation(first string, block []string) string {
+	parts := []string{first}
+	for _, line := range block {
+		if strings.TrimSpace(line) != "" {
+			parts = append(parts, strings.TrimSpace(line))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func splitList(text string) []string {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	out := make([]string, 0)
+	for _, part := range splitTopLevel(text) {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	return out
+}
+
+func splitTopLevel(text string) []string {
+	var parts []string
+	depth := 0
+	var quote rune
+	start := 0
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			depth--
+		case r == ',' && depth == 0:
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, text[start:])
+}
+
+func startsSequence(block []string) bool {
+	for _, line := range block {
+		if isBlankOrComment(line) {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		return trimmed == "-" || strings.HasPrefix(trimmed, "- ")
+	}
+	return false
+}
+
+func isBlankOrComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
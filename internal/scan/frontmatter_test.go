@@ -0,0 +1,119 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseFrontmatter(t *testing.T) {
+	document := `---
+name: pdf-tools
+description: >
+  Extract text and tables
+  from PDF files.
+version: 1.2
+license: 'Apache-2.0'
+tags: [pdf, "documents", extraction]
+allowed-tools:
+  - Read
+  - Bash(pdftotext:*, qpdf:*)
+metadata:
+  author: acme
+---
+
+# PDF tools
+`
+
+	meta, body, err := ParseFrontmatter([]byte(document))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	expected := Metadata{
+		Name:         "pdf-tools",
+		Description:  "Extract text and tables from PDF files.",
+		Version:      "1.2",
+		License:      "Apache-2.0",
+		Tags:         []string{"pdf", "documents", "extraction"},
+		AllowedTools: []string{"Read", "Bash(pdftotext:*, qpdf:*)"},
+	}
+	if !reflect.DeepEqual(meta, expected) {
+		t.Fatalf("unexpected metadata:\n%#v\nwant\n%#v", meta, expected)
+	}
+	if body != "# PDF tools\n" {
+		t.Fatalf("unexpected body: %q", body)
+	}
+}
+
+func TestParseFrontmatterCommaSeparatedLists(t *testing.T) {
+	document := "---\nname: \"quoted: name\"\nallowed-tools: Bash(git add:*, git commit:*), Read # trailing\ndescription: |\n  line one\n  line two\n---\nbody"
+
+	meta, _, err := ParseFrontmatter([]byte(document))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	if meta.Name != "quoted: name" {
+		t.Fatalf("unexpected name: %q", meta.Name)
+	}
+	if !reflect.DeepEqual(meta.AllowedTools, []string{"Bash(git add:*, git commit:*)", "Read"}) {
+		t.Fatalf("unexpected allowed tools: %#v", meta.AllowedTools)
+	}
+	if meta.Description != "line one\nline two" {
+		t.Fatalf("unexpected description: %q", meta.Description)
+	}
+}
+
+func TestParseFrontmatterErrors(t *testing.T) {
+	invalid := []string{
+		"---\nname: alpha\n",
+		"---\nname: \"unterminated\n---\n",
+		"---\ntags: [a, b\n---\n",
+		"---\njust text\n---\n",
+	}
+
+	for _, document := range invalid {
+		if _, _, err := ParseFrontmatter([]byte(document)); err == nil {
+			t.Fatalf("expected error for %q", document)
+		}
+	}
+
+	meta, body, err := ParseFrontmatter([]byte("# no frontmatter"))
+	if err != nil || meta.Name != "" || body != "# no frontmatter" {
+		t.Fatalf("expected documents without frontmatter to parse, got %#v %q %v", meta, body, err)
+	}
+}
+
+func TestScanReportsMetadataErrorsPerSkill(t *testing.T) {
+	root := t.TempDir()
+
+	skills := map[string]string{
+		"alpha": "---\ndescription: Alpha skill\ntags: [a]\n---\n# alpha",
+		"beta":  "---\ndescription: [broken\n---\n# beta",
+	}
+	for name, content := range skills {
+		if err := os.MkdirAll(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name, "SKILL.md"), []byte(content), 0o644); err != nil {
+			t.Fatalf("write marker failed: %v", err)
+		}
+	}
+
+	scanned, err := ScanRegistry(root)
+	if err != nil {
+		t.Fatalf("scan registry failed: %v", err)
+	}
+	if len(scanned) != 2 {
+		t.Fatalf("expected both skills, got %d", len(scanned))
+	}
+
+	if scanned[0].Metadata.Description != "Alpha skill" || scanned[0].MetadataErr != nil {
+		t.Fatalf("unexpected alpha metadata: %#v", scanned[0])
+	}
+	if scanned[1].MetadataErr == nil {
+		t.Fatalf("expected beta metadata error")
+	}
+}
@@ -2,16 +2,27 @@ package scan
 
 import (
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 )
 
+const MarkerFileName = "SKILL.md"
+
+const stagingPrefix = ".skiller-"
+
 type Skill struct {
-	Name   string
-	Path   string
-	Parent string
+	Name    string
+	Path    string
+	Parent  string
+	Link    string
+	Adapted bool
+
+	Metadata    Metadata
+	MetadataErr error
 }
 
 func ScanRegistry(registryPath string) ([]Skill, error) {
@@ -53,11 +64,7 @@ func ScanRegistry(registryPath string) ([]Skill, error) {
 			return nil
 		}
 
-		skills = append(skills, Skill{
-			Name:   filepath.Base(path),
-			Path:   path,
-			Parent: cleanRoot,
-		})
+		skills = append(skills, newSkill(filepath.Base(path), path, cleanRoot))
 		return filepath.SkipDir
 	})
 	if err != nil {
@@ -94,14 +101,29 @@ func ScanHarness(harnessPath string) ([]Skill, error) {
 
 	skills := make([]Skill, 0)
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if strings.HasPrefix(entry.Name(), stagingPrefix) {
 			continue
 		}
+		skillPath := filepath.Join(cleanRoot, entry.Name())
+
+		link := ""
 		if entry.Type()&os.ModeSymlink != 0 {
+			target, ok := linkedSkillDir(skillPath)
+			if !ok {
+				continue
+			}
+			link = target
+		} else if entry.Type().IsRegular() {
+			adapted, err := scanAdapted(skillPath, cleanRoot)
+			if err != nil {
+				return nil, err
+			}
+			skills = append(skills, adapted...)
+			continue
+		} else if !entry.IsDir() {
 			continue
 		}
 
-		skillPath := filepath.Join(cleanRoot, entry.Name())
 		hasSkill, err := hasSkillMarker(skillPath)
 		if err != nil {
 			return nil, err
@@ -110,19 +132,55 @@ func ScanHarness(harnessPath string) ([]Skill, error) {
 			continue
 		}
 
-		skills = append(skills, Skill{
-			Name:   entry.Name(),
-			Path:   skillPath,
-			Parent: cleanRoot,
-		})
+		skill := newSkill(entry.Name(), skillPath, cleanRoot)
+		skill.Link = link
+		skills = append(skills, skill)
 	}
 
 	sort.Slice(skills, func(i, j int) bool { return skills[i].Name < skills[j].Name })
 	return skills, nil
 }
 
+func newSkill(name, path, parent string) Skill {
+	skill := Skill{
+		Name:   name,
+		Path:   path,
+		Parent: parent,
+	}
+
+	data, err := os.ReadFile(filepath.Join(path, MarkerFileName))
+	if err != nil {
+		skill.MetadataErr = err
+		return skill
+	}
+
+	metadata, _, err := ParseFrontmatter(data)
+	if err != nil {
+		skill.MetadataErr = fmt.Errorf("%s: %w", filepath.Join(path, MarkerFileName), err)
+		return skill
+	}
+	skill.Metadata = metadata
+	return skill
+}
+
+func linkedSkillDir(path string) (string, bool) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return "", false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return "", false
+	}
+	return filepath.Clean(target), true
+}
+
 func hasSkillMarker(path string) (bool, error) {
-	markerPath := filepath.Join(path, "SKILL.md")
+	markerPath := filepath.Join(path, MarkerFileName)
 	info, err := os.Stat(markerPath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -73,6 +73,13 @@ func TestScanHarnessListsInstalledSkills(t *testing.T) {
 	if err := os.MkdirAll(filepath.Join(harness, "other"), 0o755); err != nil {
 		t.Fatalf("mkdir failed: %v", err)
 	}
+	staging := filepath.Join(harness, ".skiller-stage-123", "alpha")
+	if err := os.MkdirAll(staging, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(harness, ".skiller-stage-123", "SKILL.md"), []byte("# stage"), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
 
 	skills, err := ScanHarness(harness)
 	if err != nil {
@@ -86,3 +93,33 @@ func TestScanHarnessListsInstalledSkills(t *testing.T) {
 		t.Fatalf("expected alpha, got %s", skills[0].Name)
 	}
 }
+
+func TestScanHarnessRecognizesSymlinkedSkills(t *testing.T) {
+	root := t.TempDir()
+	harness := filepath.Join(root, "harness")
+	target := filepath.Join(root, "registry", "alpha")
+
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "SKILL.md"), []byte("# alpha"), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+	if err := os.MkdirAll(harness, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.Symlink(target, filepath.Join(harness, "alpha")); err != nil {
+		t.Fatalf("symlink failed: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(root, "missing"), filepath.Join(harness, "dangling")); err != nil {
+		t.Fatalf("symlink failed: %v", err)
+	}
+
+	skills, err := ScanHarness(harness)
+	if err != nil {
+		t.Fatalf("scan harness failed: %v", err)
+	}
+	if len(skills) != 1 || skills[0].Name != "alpha" || skills[0].Link != target {
+		t.Fatalf("expected linked alpha skill, got %#v", skills)
+	}
+}
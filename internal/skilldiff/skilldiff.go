@@ -0,0 +1,145 @@
+package skilldiff
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"skiller/internal/provenance"
+)
+
+type Status string
+
+const (
+	StatusAdded   Status = "added"
+	StatusRemoved Status = "removed"
+	StatusChanged Status = "changed"
+)
+
+type File struct {
+	Path   string
+	Status Status
+	Binary bool
+	Diff   string
+}
+
+type entry struct {
+	data []byte
+	link bool
+}
+
+func Compare(installed, source string) ([]File, error) {
+	before, err := readTree(installed)
+	if err != nil {
+		return nil, err
+	}
+	after, err := readTree(source)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(before)+len(after))
+	for path := range before {
+		paths = append(paths, path)
+	}
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var files []File
+	for _, path := range paths {
+		was, existed := before[path]
+		now, exists := after[path]
+		file := File{Path: path, Status: StatusChanged}
+		oldName, newName := "a/"+path, "b/"+path
+		switch {
+		case !existed:
+			file.Status, oldName = StatusAdded, "/dev/null"
+		case !exists:
+			file.Status, newName = StatusRemoved, "/dev/null"
+		case was.link == now.link && bytes.Equal(was.data, now.data):
+			continue
+		}
+
+		if isBinary(was.data) || isBinary(now.data) {
+			file.Binary = true
+		} else {
+			file.Diff = Unified(oldName, newName, was.data, now.data)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func Summary(files []File) string {
+	counts := map[Status]int{}
+	for _, file := range files {
+		counts[file.Status]++
+	}
+
+	var parts []string
+	for _, status := range []Status{StatusAdded, StatusChanged, StatusRemoved} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	if len(parts) == 0 {
+		return "no differences"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func readTree(root string) (map[string]entry, error) {
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := map[string]entry{}
+	err = filepath.WalkDir(resolved, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(resolved, path)
+		if err != nil {
+			return err
+		}
+		if rel == provenance.FileName {
+			return nil
+		}
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			tree[filepath.ToSlash(rel)] = entry{data: []byte(target + "\n"), link: true}
+		case d.Type().IsRegular():
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			tree[filepath.ToSlash(rel)] = entry{data: data}
+		}
+		return nil
+	})
+	return tree, err
+}
+
+func isBinary(data []byte) bool {
+	sample := data[:min(len(data), 8000)]
+	return bytes.IndexByte(sample, 0) >= 0 || !utf8.Valid(data)
+}
@@ -0,0 +1,82 @@
+package skilldiff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"skiller/internal/provenance"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+}
+
+func TestCompareListsFileChanges(t *testing.T) {
+	installed := t.TempDir()
+	source := t.TempDir()
+	writeFiles(t, installed, map[string]string{
+		"SKILL.md":          "# review\n\nStep one.\nStep two.\n",
+		"notes.md":          "local notes\n",
+		"same.md":           "unchanged\n",
+		"logo.png":          "\x89PNG\x00\x01",
+		provenance.FileName: "{}",
+	})
+	writeFiles(t, source, map[string]string{
+		"SKILL.md":       "# review\n\nStep one.\nStep 2.\nStep three.\n",
+		"same.md":        "unchanged\n",
+		"logo.png":       "\x89PNG\x00\x02",
+		"scripts/run.sh": "echo hi",
+	})
+
+	files, err := Compare(installed, source)
+	if err != nil {
+		t.Fatalf("compare failed: %v", err)
+	}
+
+	want := []struct {
+		path   string
+		status Status
+		binary bool
+		diff   string
+	}{
+		{"SKILL.md", StatusChanged, false, "--- a/SKILL.md\n+++ b/SKILL.md\n@@ -1,4 +1,5 @@\n # review\n \n Step one.\n-Step two.\n+Step 2.\n+Step three.\n"},
+		{"logo.png", StatusChanged, true, ""},
+		{"notes.md", StatusRemoved, false, "--- a/notes.md\n+++ /dev/null\n@@ -1 +0,0 @@\n-local notes\n"},
+		{"scripts/run.sh", StatusAdded, false, "--- /dev/null\n+++ b/scripts/run.sh\n@@ -0,0 +1 @@\n+echo hi\n\\ No newline at end of file\n"},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("expected %d files, got %+v", len(want), files)
+	}
+	for i, file := range files {
+		if file.Path != want[i].path || file.Status != want[i].status || file.Binary != want[i].binary || file.Diff != want[i].diff {
+			t.Fatalf("file %d: expected %+v, got %+v", i, want[i], file)
+		}
+	}
+	if summary := Summary(files); summary != "1 added, 2 changed, 1 removed" {
+		t.Fatalf("unexpected summary %q", summary)
+	}
+}
+
+func TestUnifiedSplitsDistantChangesIntoHunks(t *testing.T) {
+	before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	after := "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n"
+
+	want := "--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n"
+	if diff := Unified("a/f", "b/f", []byte(before), []byte(after)); diff != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, diff)
+	}
+	if diff := Unified("a/f", "b/f", []byte(before), []byte(before)); diff != "" {
+		t.Fatalf("expected no diff for equal files, got:\n%s", diff)
+	}
+}
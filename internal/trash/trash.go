@@ -0,0 +1,385 @@
+package trash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"skiller/internal/adapter"
+	"skiller/internal/config"
+	"skiller/internal/fsutil"
+)
+
+const (
+	entryFileName = "entry.json"
+	filesDirName  = "files"
+
+	MaxEntries = 50
+	MaxAge     = 30 * 24 * time.Hour
+)
+
+var ErrDestinationExists = errors.New("original location is occupied")
+
+type Reason string
+
+const (
+	ReasonUninstall Reason = "uninstall"
+	ReasonOverwrite Reason = "overwrite"
+	ReasonRestore   Reason = "restore"
+)
+
+type Entry struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	OriginalPath string    `json:"original_path"`
+	Reason       Reason    `json:"reason"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	Section      string    `json:"section,omitempty"`
+}
+
+func Root() (string, error) {
+	root, err := config.DataRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, config.AppName, "trash"), nil
+}
+
+func Move(path, originalPath string, reason Reason) (Entry, error) {
+	entry, err := move(path, originalPath, reason)
+	if err != nil {
+		return Entry{}, err
+	}
+	_, _ = Prune(MaxEntries, MaxAge)
+	return entry, nil
+}
+
+func move(path, originalPath string, reason Reason) (Entry, error) {
+	root, err := Root()
+	if err != nil {
+		return Entry{}, err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return Entry{}, err
+	}
+
+	original, err := filepath.Abs(originalPath)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{
+		Name:         filepath.Base(original),
+		OriginalPath: original,
+		Reason:       reason,
+		DeletedAt:    time.Now().UTC(),
+	}
+	dir, err := claimEntryDir(root, &entry)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if err := moveDir(path, filepath.Join(dir, filesDirName)); err != nil {
+		_ = os.RemoveAll(dir)
+		return Entry{}, fmt.Errorf("move %s to trash: %w", path, err)
+	}
+	if err := writeEntry(dir, entry); err != nil {
+		if restoreErr := moveDir(filepath.Join(dir, filesDirName), path); restoreErr == nil {
+			_ = os.RemoveAll(dir)
+		}
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+func MoveSection(section []byte, originalPath, name string, reason Reason) (Entry, error) {
+	entry, err := moveSection(section, originalPath, name, reason)
+	if err != nil {
+		return Entry{}, err
+	}
+	_, _ = Prune(MaxEntries, MaxAge)
+	return entry, nil
+}
+
+func moveSection(section []byte, originalPath, name string, reason Reason) (Entry, error) {
+	root, err := Root()
+	if err != nil {
+		return Entry{}, err
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return Entry{}, err
+	}
+
+	original, err := filepath.Abs(originalPath)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry := Entry{
+		Name:         name,
+		OriginalPath: original,
+		Reason:       reason,
+		DeletedAt:    time.Now().UTC(),
+		Section:      name,
+	}
+	dir, err := claimEntryDir(root, &entry)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, filesDirName), section, 0o644); err != nil {
+		_ = os.RemoveAll(dir)
+		return Entry{}, fmt.Errorf("move %s section to trash: %w", name, err)
+	}
+	if err := writeEntry(dir, entry); err != nil {
+		_ = os.RemoveAll(dir)
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+func claimEntryDir(root string, entry *Entry) (string, error) {
+	base := entry.DeletedAt.Format("20060102-150405") + "-" + entry.Name
+	for i := 1; ; i++ {
+		id := base
+		if i > 1 {
+			id = fmt.Sprintf("%s-%d", base, i)
+		}
+		dir := filepath.Join(root, id)
+		err := os.Mkdir(dir, 0o755)
+		if err == nil {
+			entry.ID = id
+			return dir, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+	}
+}
+
+func List() ([]Entry, error) {
+	root, err := Root()
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := os.ReadDir(root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Entry{}, nil
+		}
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(dirs))
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entry, err := readEntry(filepath.Join(root, dir.Name()))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].DeletedAt.Equal(entries[j].DeletedAt) {
+			return entries[i].ID > entries[j].ID
+		}
+		return entries[i].DeletedAt.After(entries[j].DeletedAt)
+	})
+	return entries, nil
+}
+
+func Find(id string) (Entry, error) {
+	root, err := Root()
+	if err != nil {
+		return Entry{}, err
+	}
+	if id == "" || id != filepath.Base(id) {
+		return Entry{}, fmt.Errorf("trash entry not found: %s", id)
+	}
+
+	entry, err := readEntry(filepath.Join(root, id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Entry{}, fmt.Errorf("trash entry not found: %s", id)
+		}
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+func Restore(id string, replace bool) (Entry, error) {
+	entry, err := Find(id)
+	if err != nil {
+		return Entry{}, err
+	}
+	root, err := Root()
+	if err != nil {
+		return Entry{}, err
+	}
+	dir := filepath.Join(root, entry.ID)
+	if entry.Section != "" {
+		return restoreSection(entry, dir, replace)
+	}
+
+	var displaced *Entry
+	if _, err := os.Lstat(entry.OriginalPath); err == nil {
+		if !replace {
+			return Entry{}, fmt.Errorf("%w: %s", ErrDestinationExists, entry.OriginalPath)
+		}
+		// Pruning now could delete the entry being restored.
+		moved, err := move(entry.OriginalPath, entry.OriginalPath, ReasonRestore)
+		if err != nil {
+			return Entry{}, err
+		}
+		displaced = &moved
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return Entry{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(entry.OriginalPath), 0o755); err != nil {
+		return Entry{}, err
+	}
+	if err := moveDir(filepath.Join(dir, filesDirName), entry.OriginalPath); err != nil {
+		if displaced != nil {
+			_, _ = Restore(displaced.ID, false)
+		}
+		return Entry{}, fmt.Errorf("restore %s: %w", entry.OriginalPath, err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return entry, err
+	}
+	if displaced != nil {
+		_, _ = Prune(MaxEntries, MaxAge)
+	}
+	return entry, nil
+}
+
+func restoreSection(entry Entry, dir string, replace bool) (Entry, error) {
+	section, err := os.ReadFile(filepath.Join(dir, filesDirName))
+	if err != nil {
+		return Entry{}, err
+	}
+	doc, err := os.ReadFile(entry.OriginalPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Entry{}, err
+	}
+
+	var displaced *Entry
+	if current, ok := adapter.Section(doc, entry.Section); ok {
+		if !replace {
+			return Entry{}, fmt.Errorf("%w: %s section in %s", ErrDestinationExists, entry.Section, entry.OriginalPath)
+		}
+		moved, err := moveSection(current, entry.OriginalPath, entry.Section, ReasonRestore)
+		if err != nil {
+			return Entry{}, err
+		}
+		displaced = &moved
+	}
+
+	if err := writeDoc(entry.OriginalPath, adapter.UpsertSection(doc, entry.Section, section)); err != nil {
+		if displaced != nil {
+			_ = os.RemoveAll(filepath.Join(filepath.Dir(dir), displaced.ID))
+		}
+		return Entry{}, fmt.Errorf("restore %s section in %s: %w", entry.Section, entry.OriginalPath, err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return entry, err
+	}
+	if displaced != nil {
+		_, _ = Prune(MaxEntries, MaxAge)
+	}
+	return entry, nil
+}
+
+func writeDoc(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func Empty(olderThan time.Duration) (int, error) {
+	return Prune(0, olderThan)
+}
+
+func Prune(maxEntries int, maxAge time.Duration) (int, error) {
+	entries, err := List()
+	if err != nil {
+		return 0, err
+	}
+	root, err := Root()
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for i, entry := range entries {
+		expired := maxAge > 0 && entry.DeletedAt.Before(cutoff)
+		overflow := maxEntries > 0 && i >= maxEntries
+		all := maxEntries == 0 && maxAge == 0
+		if !expired && !overflow && !all {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, entry.ID)); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func readEntry(dir string) (Entry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, entryFileName))
+	if err != nil {
+		return Entry{}, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, fmt.Errorf("%s: %w", filepath.Join(dir, entryFileName), err)
+	}
+	entry.ID = filepath.Base(dir)
+	return entry, nil
+}
+
+func writeEntry(dir string, entry Entry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, entryFileName), append(data, '\n'), 0o644)
+}
+
+func moveDir(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	copyPath := fsutil.CopyDir
+	if info, err := os.Lstat(src); err == nil && info.Mode().IsRegular() {
+		copyPath = fsutil.CopyFile
+	}
+	if err := copyPath(src, dst); err != nil {
+		_ = os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
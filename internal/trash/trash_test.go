@@ -0,0 +1,91 @@
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeSkill(t *testing.T, dir, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+}
+
+func TestMoveAndRestore(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	skill := filepath.Join(t.TempDir(), "harness", "alpha")
+	writeSkill(t, skill, "# alpha v1")
+
+	entry, err := Move(skill, skill, ReasonUninstall)
+	if err != nil {
+		t.Fatalf("move failed: %v", err)
+	}
+	if _, err := os.Stat(skill); !os.IsNotExist(err) {
+		t.Fatalf("expected skill to be moved away")
+	}
+
+	entries, err := List()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one trash entry: %v (%d)", err, len(entries))
+	}
+	if entries[0].ID != entry.ID || entries[0].Name != "alpha" || entries[0].OriginalPath != skill || entries[0].Reason != ReasonUninstall {
+		t.Fatalf("unexpected entry: %#v", entries[0])
+	}
+
+	writeSkill(t, skill, "# alpha v2")
+	if _, err := Restore(entry.ID, false); !errors.Is(err, ErrDestinationExists) {
+		t.Fatalf("expected occupied destination error, got %v", err)
+	}
+
+	if _, err := Restore(entry.ID, true); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(skill, "SKILL.md"))
+	if err != nil || string(data) != "# alpha v1" {
+		t.Fatalf("expected restored v1, got %q (%v)", data, err)
+	}
+
+	entries, err = List()
+	if err != nil || len(entries) != 1 || entries[0].Reason != ReasonRestore {
+		t.Fatalf("expected displaced v2 in the trash: %v %#v", err, entries)
+	}
+}
+
+func TestPruneAndEmpty(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	harness := filepath.Join(t.TempDir(), "harness")
+
+	for _, name := range []string{"alpha", "beta", "gamma"} {
+		skill := filepath.Join(harness, name)
+		writeSkill(t, skill, "# "+name)
+		if _, err := Move(skill, skill, ReasonUninstall); err != nil {
+			t.Fatalf("move failed: %v", err)
+		}
+	}
+
+	removed, err := Prune(2, 0)
+	if err != nil || removed != 1 {
+		t.Fatalf("expected one entry over the limit to be pruned: %v (%d)", err, removed)
+	}
+
+	removed, err = Empty(time.Hour)
+	if err != nil || removed != 0 {
+		t.Fatalf("expected recent entries to survive: %v (%d)", err, removed)
+	}
+
+	removed, err = Empty(0)
+	if err != nil || removed != 2 {
+		t.Fatalf("expected empty to delete everything: %v (%d)", err, removed)
+	}
+	if entries, _ := List(); len(entries) != 0 {
+		t.Fatalf("expected empty trash, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,511 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"skiller/internal/adapter"
+	"skiller/internal/config"
+	"skiller/internal/install"
+	"skiller/internal/provenance"
+	"skiller/internal/registrysync"
+	"skiller/internal/scan"
+	"skiller/internal/trash"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type markedSkill struct {
+	registry config.Registry
+	skill    scan.Skill
+}
+
+type batchInstall struct {
+	harnesses []string
+	items     []markedSkill
+	conflicts int
+}
+
+func (b *batchInstall) target() string {
+	if len(b.harnesses) == 1 {
+		return b.harnesses[0]
+	}
+	return fmt.Sprintf("%d harnesses", len(b.harnesses))
+}
+
+type batchOutcome string
+
+const (
+	outcomeInstalled   batchOutcome = "installed"
+	outcomeOverwritten batchOutcome = "overwritten"
+	outcomeRenamed     batchOutcome = "renamed"
+	outcomeSkipped     batchOutcome = "skipped"
+	outcomeUninstalled batchOutcome = "uninstalled"
+	outcomeFailed      batchOutcome = "failed"
+)
+
+type batchItem struct {
+	name    string
+	outcome batchOutcome
+	detail  string
+}
+
+type batchReport struct {
+	title  string
+	items  []batchItem
+	offset int
+}
+
+func (r *batchReport) add(name string, outcome batchOutcome, detail string) {
+	r.items = append(r.items, batchItem{name: name, outcome: outcome, detail: detail})
+}
+
+func (r *batchReport) summary() string {
+	counts := map[batchOutcome]int{}
+	for _, item := range r.items {
+		counts[item.outcome]++
+	}
+
+	var parts []string
+	for _, outcome := range []batchOutcome{outcomeInstalled, outcomeOverwritten, outcomeRenamed, outcomeUninstalled, outcomeSkipped, outcomeFailed} {
+		if counts[outcome] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[outcome], outcome))
+		}
+	}
+	if len(parts) == 0 {
+		return "nothing to do"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (m *Model) markCount() int {
+	switch m.focus {
+	case focusSkills:
+		return len(m.markedSkills)
+	case focusHarnesses:
+		return len(m.markedInstalls)
+	default:
+		return 0
+	}
+}
+
+func (m *Model) toggleMark() {
+	switch m.focus {
+	case focusSkills:
+		skill, ok := m.selectedRegistrySkill()
+		if !ok {
+			m.statusMessage = "No skill selected"
+			return
+		}
+		registry, _ := m.selectedRegistryValue()
+		if _, marked := m.markedSkills[skill.Path]; marked {
+			delete(m.markedSkills, skill.Path)
+		} else {
+			m.markedSkills[skill.Path] = markedSkill{registry: registry, skill: skill}
+		}
+	case focusHarnesses:
+		row, ok := m.selectedHarnessRowValue()
+		if !ok {
+			m.statusMessage = "No harness selected"
+			return
+		}
+		if row.kind == harnessRowHeader {
+			var rows []harnessRow
+			for _, candidate := range m.visibleHarnessRows() {
+				if candidate.kind == harnessRowSkill && candidate.harness == row.harness {
+					rows = append(rows, candidate)
+				}
+			}
+			m.setInstallMarks(rows)
+			return
+		}
+		if _, marked := m.markedInstalls[row.skill.Path]; marked {
+			delete(m.markedInstalls, row.skill.Path)
+		} else {
+			m.markedInstalls[row.skill.Path] = row
+		}
+	default:
+		m.statusMessage = "Switch to Registry Skills or Harness Installs pane to mark skills"
+		return
+	}
+
+	m.statusMessage = fmt.Sprintf("%d marked", m.markCount())
+	m.moveSelection(1)
+}
+
+func (m *Model) markRange() {
+	if m.focus != focusSkills && m.focus != focusHarnesses {
+		m.statusMessage = "Switch to Registry Skills or Harness Installs pane to mark skills"
+		return
+	}
+
+	cursor := m.selectedSkill
+	if m.focus == focusHarnesses {
+		cursor = m.selectedHarnessRow
+	}
+
+	anchor, active := m.rangeAnchor[m.focus]
+	if !active {
+		m.rangeAnchor[m.focus] = cursor
+		m.statusMessage = "Range started. Move and press V again to mark it."
+		return
+	}
+	delete(m.rangeAnchor, m.focus)
+
+	from, to := anchor, cursor
+	if from > to {
+		from, to = to, from
+	}
+
+	switch m.focus {
+	case focusSkills:
+		registry, _ := m.selectedRegistryValue()
+		skills := m.skillsForSelectedRegistry()
+		for i := from; i <= to && i < len(skills); i++ {
+			m.markedSkills[skills[i].Path] = markedSkill{registry: registry, skill: skills[i]}
+		}
+	case focusHarnesses:
+		rows := m.visibleHarnessRows()
+		for i := from; i <= to && i < len(rows); i++ {
+			if rows[i].kind == harnessRowSkill {
+				m.markedInstalls[rows[i].skill.Path] = rows[i]
+			}
+		}
+	}
+	m.statusMessage = fmt.Sprintf("%d marked", m.markCount())
+}
+
+func (m *Model) markAll() {
+	switch m.focus {
+	case focusSkills:
+		registry, ok := m.selectedRegistryValue()
+		if !ok {
+			m.statusMessage = "No registry selected"
+			return
+		}
+		skills := m.skillsForSelectedRegistry()
+		all := len(skills) > 0
+		for _, skill := range skills {
+			if _, marked := m.markedSkills[skill.Path]; !marked {
+				all = false
+				break
+			}
+		}
+		for _, skill := range skills {
+			if all {
+				delete(m.markedSkills, skill.Path)
+			} else {
+				m.markedSkills[skill.Path] = markedSkill{registry: registry, skill: skill}
+			}
+		}
+	case focusHarnesses:
+		var rows []harnessRow
+		for _, row := range m.visibleHarnessRows() {
+			if row.kind == harnessRowSkill {
+				rows = append(rows, row)
+			}
+		}
+		m.setInstallMarks(rows)
+		return
+	default:
+		m.statusMessage = "Switch to Registry Skills or Harness Installs pane to mark skills"
+		return
+	}
+	m.statusMessage = fmt.Sprintf("%d marked", m.markCount())
+}
+
+func (m *Model) setInstallMarks(rows []harnessRow) {
+	all := len(rows) > 0
+	for _, row := range rows {
+		if _, marked := m.markedInstalls[row.skill.Path]; !marked {
+			all = false
+			break
+		}
+	}
+	for _, row := range rows {
+		if all {
+			delete(m.markedInstalls, row.skill.Path)
+		} else {
+			m.markedInstalls[row.skill.Path] = row
+		}
+	}
+	m.statusMessage = fmt.Sprintf("%d marked", m.markCount())
+}
+
+func (m *Model) clearMarks() bool {
+	if len(m.markedSkills) == 0 && len(m.markedInstalls) == 0 && len(m.rangeAnchor) == 0 {
+		return false
+	}
+	m.markedSkills = map[string]markedSkill{}
+	m.markedInstalls = map[string]harnessRow{}
+	m.rangeAnchor = map[focusPane]int{}
+	m.statusMessage = "Cleared marks"
+	return true
+}
+
+func (m *Model) pruneMarks() {
+	present := map[string]bool{}
+	for _, skills := range m.registrySkills {
+		for _, skill := range skills {
+			present[skill.Path] = true
+		}
+	}
+	for _, skills := range m.harnessSkills {
+		for _, skill := range skills {
+			present[skill.Path] = true
+		}
+	}
+
+	for path := range m.markedSkills {
+		if !present[path] {
+			delete(m.markedSkills, path)
+		}
+	}
+	for path := range m.markedInstalls {
+		if !present[path] {
+			delete(m.markedInstalls, path)
+		}
+	}
+}
+
+func (m *Model) beginBatchInstall() tea.Cmd {
+	harness := m.selectedHarnessPath()
+	if harness == "" {
+		m.statusMessage = "No harness selected"
+		return nil
+	}
+	return m.startBatchInstall([]string{harness}, m.markedItems())
+}
+
+func (m *Model) markedItems() []markedSkill {
+	items := make([]markedSkill, 0, len(m.markedSkills))
+	for _, item := range m.markedSkills {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].skill.Name == items[j].skill.Name {
+			return items[i].skill.Path < items[j].skill.Path
+		}
+		return items[i].skill.Name < items[j].skill.Name
+	})
+	return items
+}
+
+func (m *Model) startBatchInstall(harnesses []string, items []markedSkill) tea.Cmd {
+	if m.installing {
+		m.statusMessage = "An install is already running"
+		return nil
+	}
+
+	conflicts := 0
+	for _, harness := range harnesses {
+		if m.cfg.HarnessConflict(harness) != config.ConflictPolicyAsk {
+			continue
+		}
+		seen := map[string]bool{}
+		for _, item := range items {
+			name := filepath.Base(item.skill.Path)
+			if installedIn(harness, name, m.cfg.HarnessFormat(harness)) || seen[name] {
+				conflicts++
+			}
+			seen[name] = true
+		}
+	}
+
+	batch := &batchInstall{harnesses: harnesses, items: items, conflicts: conflicts}
+	if conflicts == 0 {
+		return m.runBatchInstall(batch, install.ConflictSkip)
+	}
+	m.pendingBatch = batch
+	return nil
+}
+
+func installedIn(harness, name string, format adapter.Format) bool {
+	if format == adapter.FormatSkill {
+		_, err := os.Lstat(filepath.Join(harness, name))
+		return err == nil
+	}
+	if !format.Shared() {
+		if _, err := os.Lstat(filepath.Join(harness, format.FileName(name))); err == nil {
+			return true
+		}
+	}
+	_, ok, _ := scan.FindAdapted(harness, name)
+	return ok
+}
+
+func (m *Model) updateBatchConflict(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var action install.ConflictAction
+	switch msg.String() {
+	case "o":
+		action = install.ConflictOverwrite
+	case "r":
+		action = install.ConflictRename
+	case "s":
+		action = install.ConflictSkip
+	case "esc", "n":
+		m.pendingBatch = nil
+		m.statusMessage = "Cancelled batch install"
+		return m, nil
+	default:
+		return m, nil
+	}
+
+	batch := m.pendingBatch
+	m.pendingBatch = nil
+	return m, m.runBatchInstall(batch, action)
+}
+
+type batchInstallDoneMsg struct {
+	report  *batchReport
+	trashed []trash.Entry
+}
+
+type batchTarget struct {
+	harness string
+	action  install.ConflictAction
+	opts    install.Options
+}
+
+func (m *Model) runBatchInstall(batch *batchInstall, action install.ConflictAction) tea.Cmd {
+	targets := make([]batchTarget, 0, len(batch.harnesses))
+	for _, harness := range batch.harnesses {
+		target := batchTarget{harness: harness, action: action, opts: install.Options{Mode: m.cfg.HarnessMode(harness), Format: m.cfg.HarnessFormat(harness)}}
+		if policy := m.cfg.HarnessConflict(harness); policy != config.ConflictPolicyAsk {
+			target.action = install.ConflictAction(policy)
+		}
+		targets = append(targets, target)
+	}
+
+	m.installing = true
+	m.statusMessage = fmt.Sprintf("Installing %d skills...", len(batch.items)*len(targets))
+	return func() tea.Msg {
+		return runBatch(batch, targets)
+	}
+}
+
+func runBatch(batch *batchInstall, targets []batchTarget) batchInstallDoneMsg {
+	done := batchInstallDoneMsg{report: &batchReport{title: fmt.Sprintf("Install into %s", batch.target())}}
+	report := done.report
+
+	for _, target := range targets {
+		harness := target.harness
+		for _, item := range batch.items {
+			name := item.skill.Name
+			if len(batch.harnesses) > 1 {
+				name += " -> " + harness
+			}
+
+			if err := registrysync.FetchSkill(item.registry, item.skill.Path); err != nil {
+				report.add(name, outcomeFailed, err.Error())
+				continue
+			}
+			record, err := provenance.ForSkill(item.registry, item.skill)
+			if err != nil {
+				report.add(name, outcomeFailed, err.Error())
+				continue
+			}
+
+			opts := target.opts
+			opts.Provenance = &record
+			result, err := install.InstallSkillWithOptions(item.skill.Path, harness, target.action, opts)
+			switch {
+			case err != nil:
+				report.add(name, outcomeFailed, err.Error())
+			case !result.Installed:
+				report.add(name, outcomeSkipped, "already installed")
+			case result.Renamed:
+				report.add(name, outcomeRenamed, "as "+result.Name)
+			case result.Trashed != nil:
+				done.trashed = append(done.trashed, *result.Trashed)
+				report.add(name, outcomeOverwritten, "previous version in trash")
+			default:
+				report.add(name, outcomeInstalled, "")
+			}
+		}
+	}
+	return done
+}
+
+func (m *Model) handleBatchInstallDone(msg batchInstallDoneMsg) {
+	m.installing = false
+	for _, entry := range msg.trashed {
+		m.pushUndo(entry, true)
+	}
+	m.markedSkills = map[string]markedSkill{}
+	m.finishBatch(msg.report)
+}
+
+func (m *Model) beginBatchUninstall() {
+	m.showConfirm = true
+	m.confirmKind = confirmBatchUninstall
+	m.confirmMessage = fmt.Sprintf("Uninstall %d marked skills?", len(m.markedInstalls))
+}
+
+func (m *Model) runBatchUninstall() {
+	rows := make([]harnessRow, 0, len(m.markedInstalls))
+	for _, row := range m.markedInstalls {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].skill.Path < rows[j].skill.Path })
+
+	report := &batchReport{title: "Batch uninstall"}
+	for _, row := range rows {
+		name := filepath.Join(filepath.Base(row.harness), row.skill.Name)
+		entry, err := install.UninstallSkill(row.harness, row.skill.Name, m.registries)
+		if err != nil {
+			report.add(name, outcomeFailed, err.Error())
+			continue
+		}
+		m.pushUninstallUndo(entry)
+		report.add(name, outcomeUninstalled, "from "+row.harness)
+	}
+
+	m.markedInstalls = map[string]harnessRow{}
+	m.finishBatch(report)
+}
+
+func (m *Model) finishBatch(report *batchReport) {
+	m.report = report
+	m.statusMessage = fmt.Sprintf("%s: %s", report.title, report.summary())
+	m.rescan()
+}
+
+func (m *Model) updateReport(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "enter", "esc", "q":
+		m.report = nil
+	case "up", "k":
+		m.report.offset = maxInt(0, m.report.offset-1)
+	case "down", "j":
+		m.report.offset = clamp(m.report.offset+1, 0, len(m.report.items)-1)
+	}
+	return m, nil
+}
+
+func (m *Model) renderReportPane(width, height int) string {
+	report := m.report
+	title := paneTitleStyle(true).Render(truncate(report.title, width-4))
+	lines := []string{title, mutedStyle.Render(truncate(report.summary(), width-2))}
+
+	rows := maxInt(1, height-3)
+	end := minInt(len(report.items), report.offset+rows)
+	for _, item := range report.items[report.offset:end] {
+		line := fmt.Sprintf("%-11s %s", item.outcome, item.name)
+		if item.detail != "" {
+			line += "  " + item.detail
+		}
+		line = truncate(line, width-2)
+		if item.outcome == outcomeFailed {
+			line = errorStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	return paneBoxStyle(width, height, true).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"skiller/internal/config"
+	"skiller/internal/install"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newBatchModel(t *testing.T) (*Model, string) {
+	t.Helper()
+	m := newTestModel(t)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	root := t.TempDir()
+	registryRoot := filepath.Join(root, "registry")
+	for _, name := range []string{"alpha", "beta", "gamma"} {
+		if err := os.MkdirAll(filepath.Join(registryRoot, name), 0o755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(registryRoot, name, "SKILL.md"), []byte("# "+name), 0o644); err != nil {
+			t.Fatalf("write marker failed: %v", err)
+		}
+	}
+	harness := filepath.Join(root, "harness")
+	if _, err := install.InstallSkill(filepath.Join(registryRoot, "beta"), harness, install.ConflictSkip); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	m.registries = []config.Registry{{ID: "local1", Type: config.RegistryTypeLocal, Source: registryRoot}}
+	m.harnesses = []string{harness}
+	m.rescan()
+	return m, harness
+}
+
+func TestBatchInstallAppliesOneConflictPolicy(t *testing.T) {
+	m, harness := newBatchModel(t)
+
+	m.focus = focusSkills
+	m.markAll()
+	if len(m.markedSkills) != 3 {
+		t.Fatalf("expected all registry skills marked, got %d", len(m.markedSkills))
+	}
+
+	m.beginInstall()
+	if m.pendingBatch == nil || m.pendingBatch.conflicts != 1 {
+		t.Fatalf("expected one conflict to prompt for a policy, got %#v", m.pendingBatch)
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if cmd == nil {
+		t.Fatalf("expected the batch to run as a command")
+	}
+	if m.report != nil {
+		t.Fatalf("expected no report before the command finishes")
+	}
+	m.Update(cmd())
+	if m.report == nil {
+		t.Fatalf("expected a batch report")
+	}
+	if got := m.report.summary(); got != "2 installed, 1 renamed" {
+		t.Fatalf("unexpected summary %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(harness, "beta-2", "SKILL.md")); err != nil {
+		t.Fatalf("expected renamed install: %v", err)
+	}
+	if len(m.markedSkills) != 0 {
+		t.Fatalf("expected marks to be cleared after the batch")
+	}
+}
+
+func TestInstallRunsInTheBackground(t *testing.T) {
+	m, harness := newBatchModel(t)
+
+	m.focus = focusSkills
+	m.selectedSkill = 0
+	cmd := m.beginInstall()
+	if cmd == nil {
+		t.Fatalf("expected the install to run as a command")
+	}
+	if m.beginInstall() != nil {
+		t.Fatalf("expected a second install to wait for the first")
+	}
+
+	m.Update(cmd())
+	if m.installing {
+		t.Fatalf("expected the install to be finished")
+	}
+	if m.statusMessage != "Installed alpha" {
+		t.Fatalf("unexpected status %q", m.statusMessage)
+	}
+	if _, err := os.Stat(filepath.Join(harness, "alpha", "SKILL.md")); err != nil {
+		t.Fatalf("expected alpha to be installed: %v", err)
+	}
+}
+
+func TestBatchInstallFindsConflictsInAdaptedHarnesses(t *testing.T) {
+	m, _ := newBatchModel(t)
+	rules := filepath.Join(t.TempDir(), "rules")
+	m.cfg.Harnesses = []config.Harness{{Path: rules, Kind: "cursor", Enabled: true}}
+	if _, err := install.InstallSkillWithOptions(filepath.Join(m.registries[0].Source, "alpha"), rules, install.ConflictSkip, install.Options{Format: m.cfg.HarnessFormat(rules)}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	m.focus = focusSkills
+	m.markAll()
+	m.startBatchInstall([]string{rules}, m.markedItems())
+	if m.pendingBatch == nil || m.pendingBatch.conflicts != 1 {
+		t.Fatalf("expected the installed rule to count as a conflict, got %#v", m.pendingBatch)
+	}
+}
+
+func TestMarkRangeAndBatchUninstall(t *testing.T) {
+	m, harness := newBatchModel(t)
+	if _, err := install.InstallSkill(filepath.Join(m.registries[0].Source, "alpha"), harness, install.ConflictSkip); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	m.rescan()
+
+	m.focus = focusHarnesses
+	m.selectedHarnessRow = 1
+	m.markRange()
+	m.moveSelection(1)
+	m.markRange()
+	if len(m.markedInstalls) != 2 {
+		t.Fatalf("expected range to mark both installs, got %d", len(m.markedInstalls))
+	}
+
+	m.beginUninstall()
+	if m.confirmKind != confirmBatchUninstall {
+		t.Fatalf("expected batch uninstall confirmation")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+
+	if m.report == nil || m.report.summary() != "2 uninstalled" {
+		t.Fatalf("unexpected report %#v", m.report)
+	}
+	if len(m.harnessSkills[harness]) != 0 {
+		t.Fatalf("expected harness to be empty, got %d skills", len(m.harnessSkills[harness]))
+	}
+	if len(m.undo) != 2 {
+		t.Fatalf("expected each uninstall to be undoable, got %d", len(m.undo))
+	}
+}
+
+func TestHarnessPickerInstallsIntoEveryCheckedHarness(t *testing.T) {
+	m, harness := newBatchModel(t)
+	second := harness + "-second"
+	m.harnesses = []string{harness, second}
+	m.rescan()
+
+	m.focus = focusSkills
+	m.selectedSkill = 0
+	m.beginHarnessPicker()
+	if m.picker == nil || len(m.picker.items) != 1 {
+		t.Fatalf("expected picker for the selected skill")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatalf("expected the picker to start the install")
+	}
+	m.Update(cmd())
+	if m.report == nil || m.report.summary() != "2 installed" {
+		t.Fatalf("unexpected report %#v", m.report)
+	}
+	for _, path := range []string{harness, second} {
+		if _, err := os.Stat(filepath.Join(path, "alpha", "SKILL.md")); err != nil {
+			t.Fatalf("expected alpha in %s: %v", path, err)
+		}
+	}
+}
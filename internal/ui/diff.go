@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"skiller/internal/registrysync"
+	"skiller/internal/skilldiff"
+	"skiller/internal/upgrade"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type skillDiff struct {
+	name      string
+	installed string
+	source    string
+	files     []skilldiff.File
+
+	viewport viewport.Model
+	width    int
+}
+
+func newSkillDiff(name, installed, source string) (*skillDiff, error) {
+	if info, err := os.Stat(installed); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s has no installed folder to compare in %s", name, filepath.Dir(installed))
+	}
+	files, err := skilldiff.Compare(installed, source)
+	if err != nil {
+		return nil, err
+	}
+	return &skillDiff{name: name, installed: installed, source: source, files: files, viewport: viewport.New(0, 0)}, nil
+}
+
+func (d *skillDiff) setSize(width, height int) {
+	d.viewport.Width = width
+	d.viewport.Height = height
+	if width == d.width {
+		return
+	}
+
+	d.width = width
+	d.viewport.SetContent(strings.Join(d.render(width), "\n"))
+}
+
+func (d *skillDiff) render(width int) []string {
+	lines := []string{
+		mutedStyle.Render(truncate("Installed: "+d.installed, width)),
+		mutedStyle.Render(truncate("Registry:  "+d.source, width)),
+		"",
+	}
+	if len(d.files) == 0 {
+		return append(lines, statusStyle.Render("The installed copy matches the registry version."))
+	}
+
+	lines = append(lines, headingStyle.Render(skilldiff.Summary(d.files)))
+	for _, file := range d.files {
+		line := fmt.Sprintf("  %-8s %s", file.Status, file.Path)
+		if file.Binary {
+			line += " (binary)"
+		}
+		lines = append(lines, truncate(line, width))
+	}
+
+	for _, file := range d.files {
+		if file.Diff == "" {
+			continue
+		}
+		lines = append(lines, "")
+		for _, line := range strings.Split(strings.TrimSuffix(file.Diff, "\n"), "\n") {
+			lines = append(lines, diffLineStyle(line).Render(truncate(line, width)))
+		}
+	}
+	return lines
+}
+
+func diffLineStyle(line string) lipgloss.Style {
+	switch {
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, `\`):
+		return mutedStyle
+	case strings.HasPrefix(line, "@@"):
+		return headingStyle
+	case strings.HasPrefix(line, "+"):
+		return statusStyle
+	case strings.HasPrefix(line, "-"):
+		return errorStyle
+	default:
+		return lipgloss.NewStyle()
+	}
+}
+
+func (d *skillDiff) update(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "g", "home":
+		d.viewport.GotoTop()
+		return nil
+	case "G", "end":
+		d.viewport.GotoBottom()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	d.viewport, cmd = d.viewport.Update(msg)
+	return cmd
+}
+
+func (m *Model) beginDiff() tea.Cmd {
+	m.errorMessage = ""
+	m.statusMessage = ""
+
+	switch m.focus {
+	case focusSkills:
+		skill, ok := m.selectedRegistrySkill()
+		if !ok {
+			m.statusMessage = "No skill selected"
+			return nil
+		}
+		harness := m.selectedHarnessPath()
+		if harness == "" {
+			m.statusMessage = "No harness selected"
+			return nil
+		}
+		registry, _ := m.selectedRegistryValue()
+		return m.openDiff(registry.ID, skill.Name, filepath.Join(harness, filepath.Base(skill.Path)), skill.Path)
+	case focusHarnesses:
+		row, ok := m.selectedHarnessRowValue()
+		if !ok || row.kind != harnessRowSkill {
+			m.statusMessage = "Select an installed skill to diff"
+			return nil
+		}
+		status, ok := m.harnessStatus[row.skill.Path]
+		if !ok || status.Source.Path == "" {
+			state := upgrade.StateUnmanaged
+			if ok {
+				state = status.State
+			}
+			m.statusMessage = fmt.Sprintf("%s has no registry version to compare with (%s)", row.skill.Name, state)
+			return nil
+		}
+		return m.openDiff(status.Registry.ID, row.skill.Name, row.skill.Path, status.Source.Path)
+	default:
+		m.statusMessage = "Select a registry skill or installed skill to diff"
+		return nil
+	}
+}
+
+func (m *Model) diffPendingInstall() tea.Cmd {
+	m.errorMessage = ""
+	destination := filepath.Join(m.pendingHarness, filepath.Base(m.pendingSkill.Path))
+	if m.pendingInstallOptions.Name != "" {
+		destination = filepath.Join(m.pendingHarness, m.pendingInstallOptions.Name)
+	}
+	return m.openDiff("", m.pendingSkill.Name, destination, m.pendingSkill.Path)
+}
+
+type diffReadyMsg struct {
+	diff *skillDiff
+	err  error
+}
+
+func (m *Model) openDiff(registryID, name, installed, source string) tea.Cmd {
+	registry, ok := m.registryByID(registryID)
+	if !ok {
+		m.showDiff(newSkillDiff(name, installed, source))
+		return nil
+	}
+
+	m.statusMessage = fmt.Sprintf("Fetching %s...", name)
+	return func() tea.Msg {
+		if err := registrysync.FetchSkill(registry, source); err != nil {
+			return diffReadyMsg{err: err}
+		}
+		diff, err := newSkillDiff(name, installed, source)
+		return diffReadyMsg{diff: diff, err: err}
+	}
+}
+
+func (m *Model) showDiff(diff *skillDiff, err error) {
+	m.statusMessage = ""
+	if err != nil {
+		m.errorMessage = err.Error()
+		return
+	}
+	m.diff = diff
+	m.resizePreview()
+}
+
+func (m *Model) updateDiff(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "D", "q", "esc":
+		m.diff = nil
+		return m, nil
+	}
+
+	return m, m.diff.update(msg)
+}
+
+func (m *Model) renderDiffPane(width, height int) string {
+	title := paneTitleStyle(true).Render(truncate("Diff: "+m.diff.name, width-4))
+	position := fmt.Sprintf("%3.0f%%", m.diff.viewport.ScrollPercent()*100)
+	lines := []string{title, m.diff.viewport.View(), mutedStyle.Render(position)}
+	return paneBoxStyle(width, height, true).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"skiller/internal/scan"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestConflictPromptViewsDiff(t *testing.T) {
+	m := newTestModel(t)
+	m.width, m.height = 120, 40
+
+	source := filepath.Join(t.TempDir(), "review")
+	harness := t.TempDir()
+	installed := filepath.Join(harness, "review")
+	for dir, content := range map[string]string{source: "# review\nUse the checklist.\n", installed: "# review\nLocal tweak.\n"} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, scan.MarkerFileName), []byte(content), 0o644); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	m.pendingSkill = scan.Skill{Name: "review", Path: source}
+	m.pendingHarness = harness
+	m.showConflict = true
+	if view := m.View(); !strings.Contains(view, "[d] view diff") {
+		t.Fatalf("expected the conflict prompt to offer a diff, got:\n%s", view)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if m.diff == nil {
+		t.Fatalf("expected the diff pane to open, error: %s", m.errorMessage)
+	}
+	view := m.View()
+	for _, want := range []string{"Diff: review", "1 changed", "changed  SKILL.md", "@@ -1,2 +1,2 @@", "-Local tweak.", "+Use the checklist."} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in the view, got:\n%s", want, view)
+		}
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.diff != nil || !m.showConflict {
+		t.Fatalf("expected closing the diff to return to the conflict prompt")
+	}
+	if data, err := os.ReadFile(filepath.Join(installed, scan.MarkerFileName)); err != nil || !strings.Contains(string(data), "Local tweak.") {
+		t.Fatalf("expected viewing the diff to leave the install alone, got %q (%v)", data, err)
+	}
+}
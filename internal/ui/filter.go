@@ -0,0 +1,235 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"skiller/internal/config"
+	"skiller/internal/scan"
+)
+
+func fuzzyScore(pattern, text string) (int, bool) {
+	needle := []rune(strings.ToLower(pattern))
+	haystack := []rune(strings.ToLower(text))
+	if len(needle) == 0 {
+		return 0, true
+	}
+
+	score, matched, previous := 0, 0, -2
+	for i := 0; i < len(haystack) && matched < len(needle); i++ {
+		if haystack[i] != needle[matched] {
+			continue
+		}
+
+		score++
+		if i == previous+1 {
+			score += 5
+		}
+		if i == 0 || isWordBoundary(haystack[i-1]) {
+			score += 3
+		}
+		previous = i
+		matched++
+	}
+	if matched < len(needle) {
+		return 0, false
+	}
+
+	if idx := strings.Index(string(haystack), string(needle)); idx >= 0 {
+		score += 10
+		if idx == 0 {
+			score += 5
+		}
+	}
+	return score - len(haystack)/20, true
+}
+
+func isWordBoundary(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("-_/.:", r)
+}
+
+func matchFields(query string, fields []weightedField) (int, bool) {
+	total := 0
+	for _, term := range strings.Fields(query) {
+		best, found := 0, false
+		for _, field := range fields {
+			if field.text == "" {
+				continue
+			}
+			if score, ok := fuzzyScore(term, field.text); ok && (!found || score*field.weight > best) {
+				best, found = score*field.weight, true
+			}
+		}
+		if !found {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+type weightedField struct {
+	text   string
+	weight int
+}
+
+func skillFields(skill scan.Skill) []weightedField {
+	fields := []weightedField{
+		{text: skill.Name, weight: 3},
+		{text: skill.Metadata.Name, weight: 3},
+		{text: skill.Metadata.Description, weight: 1},
+	}
+	for _, tag := range skill.Metadata.Tags {
+		fields = append(fields, weightedField{text: tag, weight: 2})
+	}
+	return fields
+}
+
+func matchSkill(query string, skill scan.Skill) (int, bool) {
+	return matchFields(query, skillFields(skill))
+}
+
+func matchRegistry(query string, registry config.Registry) (int, bool) {
+	return matchFields(query, []weightedField{
+		{text: registry.DisplayName(), weight: 3},
+		{text: registry.Source, weight: 1},
+	})
+}
+
+func rankByScore(count int, query string, match func(int) (int, bool)) []int {
+	type ranked struct{ index, score int }
+
+	matches := make([]ranked, 0, count)
+	for i := 0; i < count; i++ {
+		if strings.TrimSpace(query) == "" {
+			matches = append(matches, ranked{index: i})
+			continue
+		}
+		if score, ok := match(i); ok {
+			matches = append(matches, ranked{index: i, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	indexes := make([]int, len(matches))
+	for i, match := range matches {
+		indexes[i] = match.index
+	}
+	return indexes
+}
+
+func filterSkills(skills []scan.Skill, query string) []scan.Skill {
+	indexes := rankByScore(len(skills), query, func(i int) (int, bool) {
+		return matchSkill(query, skills[i])
+	})
+
+	filtered := make([]scan.Skill, len(indexes))
+	for i, index := range indexes {
+		filtered[i] = skills[index]
+	}
+	return filtered
+}
+
+func (m *Model) visibleRegistries() []config.Registry {
+	query := m.filters[focusRegistries]
+	if query == "" {
+		return m.registries
+	}
+
+	indexes := rankByScore(len(m.registries), query, func(i int) (int, bool) {
+		return matchRegistry(query, m.registries[i])
+	})
+
+	registries := make([]config.Registry, len(indexes))
+	for i, index := range indexes {
+		registries[i] = m.registries[index]
+	}
+	return registries
+}
+
+func (m *Model) visibleHarnessRows() []harnessRow {
+	query := m.filters[focusHarnesses]
+	if query == "" {
+		return m.harnessRows
+	}
+
+	rows := make([]harnessRow, 0)
+	for _, harness := range m.harnesses {
+		skills := m.harnessSkills[harness]
+		if _, ok := fuzzyScore(query, m.harnessLabel(harness)); !ok {
+			skills = filterSkills(skills, query)
+			if len(skills) == 0 {
+				continue
+			}
+		}
+
+		rows = append(rows, harnessRow{kind: harnessRowHeader, harness: harness})
+		for _, skill := range skills {
+			rows = append(rows, harnessRow{kind: harnessRowSkill, harness: harness, skill: skill})
+		}
+	}
+	return rows
+}
+
+func (m *Model) beginFilter() {
+	m.errorMessage = ""
+	m.statusMessage = ""
+
+	m.inputPrompt = "Filter " + strings.ToLower(focusTitle(m.focus))
+	m.inputTarget = inputFilter
+	m.input.SetValue(m.filters[m.focus])
+	m.input.CursorEnd()
+	m.showInput = true
+}
+
+func (m *Model) setFilter(query string) {
+	if m.filters[m.focus] == query {
+		return
+	}
+
+	m.filters[m.focus] = query
+	switch m.focus {
+	case focusRegistries:
+		m.selectedRegistry = 0
+		m.selectedSkill = 0
+	case focusSkills:
+		m.selectedSkill = 0
+	case focusHarnesses:
+		m.selectedHarnessRow = 0
+		if rows := m.visibleHarnessRows(); len(rows) > 1 {
+			m.selectedHarnessRow = 1
+		}
+	}
+}
+
+func (m *Model) clearFilter() bool {
+	if m.filters[m.focus] == "" {
+		return false
+	}
+	m.setFilter("")
+	m.statusMessage = "Cleared filter"
+	return true
+}
+
+func filterLabel(title, query string, shown, total int) string {
+	if query == "" {
+		return title
+	}
+	return fmt.Sprintf("%s /%s (%d/%d)", title, query, shown, total)
+}
+
+func focusTitle(focus focusPane) string {
+	switch focus {
+	case focusSkills:
+		return "Registry Skills"
+	case focusHarnesses:
+		return "Harness Installs"
+	default:
+		return "Registries"
+	}
+}
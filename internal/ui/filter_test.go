@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"testing"
+
+	"skiller/internal/scan"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("gcm", "git-commit-message"); !ok {
+		t.Fatalf("expected subsequence match")
+	}
+	if _, ok := fuzzyScore("xyz", "git-commit-message"); ok {
+		t.Fatalf("expected no match")
+	}
+
+	boundary, _ := fuzzyScore("gcm", "git-commit-message")
+	scattered, _ := fuzzyScore("gcm", "plugin-scheme")
+	if boundary <= scattered {
+		t.Fatalf("expected word boundary match to rank higher: %d <= %d", boundary, scattered)
+	}
+
+	prefix, _ := fuzzyScore("pdf", "pdf-tools")
+	inner, _ := fuzzyScore("pdf", "export-pdf")
+	if prefix <= inner {
+		t.Fatalf("expected prefix match to rank higher: %d <= %d", prefix, inner)
+	}
+}
+
+func TestFilterSkills(t *testing.T) {
+	skills := []scan.Skill{
+		{Name: "alpha", Metadata: scan.Metadata{Description: "Write release notes"}},
+		{Name: "beta", Metadata: scan.Metadata{Tags: []string{"pdf", "documents"}}},
+		{Name: "pdf-reader"},
+	}
+
+	names := func(filtered []scan.Skill) []string {
+		out := make([]string, len(filtered))
+		for i, skill := range filtered {
+			out[i] = skill.Name
+		}
+		return out
+	}
+
+	if got := names(filterSkills(skills, "")); len(got) != 3 || got[0] != "alpha" {
+		t.Fatalf("expected empty filter to keep order, got %v", got)
+	}
+	if got := names(filterSkills(skills, "release")); len(got) != 1 || got[0] != "alpha" {
+		t.Fatalf("expected description match, got %v", got)
+	}
+	if got := names(filterSkills(skills, "pdf")); len(got) != 2 || got[0] != "pdf-reader" || got[1] != "beta" {
+		t.Fatalf("expected name match before tag match, got %v", got)
+	}
+	if got := names(filterSkills(skills, "pdf doc")); len(got) != 1 || got[0] != "beta" {
+		t.Fatalf("expected every term to match, got %v", got)
+	}
+}
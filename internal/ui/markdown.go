@@ -0,0 +1,286 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	listItemPattern = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	headingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	rulePattern     = regexp.MustCompile(`^\s*([-*_])(\s*([-*_]))*\s*$`)
+)
+
+type markdownBlock struct {
+	text   string
+	prefix string
+	hang   string
+	style  lipgloss.Style
+}
+
+func renderMarkdown(src string, width int) []string {
+	width = maxInt(width, 10)
+
+	var out []string
+	var block *markdownBlock
+	flush := func() {
+		if block != nil {
+			out = append(out, wrapMarkdown(block, width)...)
+			block = nil
+		}
+	}
+	blank := func() {
+		if len(out) > 0 && out[len(out)-1] != "" {
+			out = append(out, "")
+		}
+	}
+
+	fence := ""
+	for _, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+				blank()
+				continue
+			}
+			out = append(out, codeStyle.Render("  "+expandTabs(line)))
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			blank()
+			fence = trimmed[:3]
+			if lang := strings.TrimSpace(trimmed[3:]); lang != "" {
+				out = append(out, mutedStyle.Render("  "+lang))
+			}
+		case trimmed == "":
+			flush()
+			blank()
+		case headingPattern.MatchString(trimmed):
+			flush()
+			blank()
+			match := headingPattern.FindStringSubmatch(trimmed)
+			style := headingStyle
+			if len(match[1]) == 1 {
+				style = titleStyle
+			}
+			out = append(out, wrapMarkdown(&markdownBlock{text: match[2], style: style}, width)...)
+			blank()
+		case rulePattern.MatchString(trimmed) && len(strings.ReplaceAll(trimmed, " ", "")) >= 3:
+			flush()
+			out = append(out, mutedStyle.Render(strings.Repeat("─", width)))
+		case strings.HasPrefix(trimmed, "|"):
+			flush()
+			out = append(out, expandTabs(trimmed))
+		case strings.HasPrefix(trimmed, ">"):
+			text := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			if block != nil && block.prefix == "│ " {
+				block.text += " " + text
+				continue
+			}
+			flush()
+			block = &markdownBlock{text: text, prefix: "│ ", hang: "│ ", style: mutedStyle}
+		case listItemPattern.MatchString(line):
+			flush()
+			match := listItemPattern.FindStringSubmatch(line)
+			indent := strings.Repeat("  ", len(expandTabs(match[1]))/2)
+			marker := "• "
+			if match[2] != "-" && match[2] != "*" && match[2] != "+" {
+				marker = match[2] + " "
+			}
+			block = &markdownBlock{
+				text:   match[3],
+				prefix: indent + marker,
+				hang:   indent + strings.Repeat(" ", len([]rune(marker))),
+			}
+		default:
+			if block != nil {
+				block.text += " " + trimmed
+				continue
+			}
+			block = &markdownBlock{text: trimmed}
+		}
+	}
+	flush()
+
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+type inlineSpan struct {
+	text  string
+	style lipgloss.Style
+}
+
+type inlinePiece struct {
+	text  string
+	style lipgloss.Style
+	glued bool
+}
+
+func wrapMarkdown(block *markdownBlock, width int) []string {
+	available := maxInt(width-len([]rune(block.prefix)), 4)
+
+	var words [][]inlinePiece
+	for _, span := range parseInline(block.text) {
+		style := span.style.Inherit(block.style)
+		fields := strings.Fields(span.text)
+		for i, field := range fields {
+			glued := i == 0 && !startsWithSpace(span.text) && len(words) > 0
+			piece := inlinePiece{text: field, style: style, glued: glued}
+			if glued {
+				words[len(words)-1] = append(words[len(words)-1], piece)
+			} else {
+				words = append(words, []inlinePiece{piece})
+			}
+		}
+		if endsWithSpace(span.text) && len(words) > 0 {
+			words = append(words, nil)
+		}
+	}
+
+	var lines []string
+	var current strings.Builder
+	currentWidth := 0
+	emit := func() {
+		prefix := block.prefix
+		if len(lines) > 0 {
+			prefix = block.hang
+		}
+		lines = append(lines, block.style.Render(prefix)+current.String())
+		current.Reset()
+		currentWidth = 0
+	}
+
+	for _, word := range words {
+		if len(word) == 0 {
+			continue
+		}
+
+		wordWidth := 0
+		for _, piece := range word {
+			wordWidth += len([]rune(piece.text))
+		}
+
+		if currentWidth > 0 && currentWidth+1+wordWidth > available {
+			emit()
+		}
+		if currentWidth > 0 {
+			current.WriteString(block.style.Render(" "))
+			currentWidth++
+		}
+
+		for _, piece := range word {
+			runes := []rune(piece.text)
+			for len(runes) > 0 {
+				room := available - currentWidth
+				if room <= 0 {
+					emit()
+					room = available
+				}
+				take := minInt(room, len(runes))
+				current.WriteString(piece.style.Render(string(runes[:take])))
+				currentWidth += take
+				runes = runes[take:]
+			}
+		}
+	}
+	if currentWidth > 0 || len(lines) == 0 {
+		emit()
+	}
+
+	return lines
+}
+
+func parseInline(text string) []inlineSpan {
+	var spans []inlineSpan
+	var plain strings.Builder
+	push := func(span inlineSpan) {
+		if plain.Len() > 0 {
+			spans = append(spans, inlineSpan{text: plain.String(), style: lipgloss.NewStyle()})
+			plain.Reset()
+		}
+		spans = append(spans, span)
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case rest[0] == '`':
+			if end := strings.Index(rest[1:], "`"); end >= 0 {
+				push(inlineSpan{text: rest[1 : end+1], style: inlineCodeStyle})
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if end := strings.Index(rest[2:], rest[:2]); end > 0 {
+				for _, inner := range parseInline(rest[2 : end+2]) {
+					push(inlineSpan{text: inner.text, style: inner.style.Bold(true)})
+				}
+				i += end + 4
+				continue
+			}
+		case rest[0] == '*':
+			if end := strings.Index(rest[1:], "*"); end > 0 && rest[1] != ' ' {
+				push(inlineSpan{text: rest[1 : end+1], style: lipgloss.NewStyle().Italic(true)})
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "!["), rest[0] == '[':
+			image := rest[0] == '!'
+			open := strings.Index(rest, "[")
+			closeText := strings.Index(rest, "](")
+			if closeText > open {
+				if closeURL := strings.Index(rest[closeText:], ")"); closeURL > 0 {
+					label := rest[open+1 : closeText]
+					url := rest[closeText+2 : closeText+closeURL]
+					if image {
+						push(inlineSpan{text: "[image: " + label + "]", style: mutedStyle})
+					} else {
+						push(inlineSpan{text: label, style: linkStyle})
+						if url != "" && url != label {
+							push(inlineSpan{text: " (" + url + ")", style: mutedStyle})
+						}
+					}
+					i += closeText + closeURL + 1
+					continue
+				}
+			}
+		}
+
+		plain.WriteByte(text[i])
+		i++
+	}
+	if plain.Len() > 0 {
+		spans = append(spans, inlineSpan{text: plain.String(), style: lipgloss.NewStyle()})
+	}
+
+	return spans
+}
+
+func startsWithSpace(text string) bool {
+	return text != "" && strings.TrimLeft(text, " \t") != text
+}
+
+func endsWithSpace(text string) bool {
+	return text != "" && strings.TrimRight(text, " \t") != text
+}
+
+func expandTabs(text string) string {
+	return strings.ReplaceAll(text, "\t", "    ")
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -10,9 +10,12 @@ import (
 
 	"skiller/internal/config"
 	"skiller/internal/install"
+	"skiller/internal/provenance"
 	"skiller/internal/registrysync"
 	"skiller/internal/scan"
+	"skiller/internal/upgrade"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -32,6 +35,7 @@ const (
 	inputNone inputTarget = iota
 	inputRegistry
 	inputHarness
+	inputFilter
 )
 
 type confirmKind int
@@ -41,6 +45,8 @@ const (
 	confirmDeleteRegistry
 	confirmDeleteHarness
 	confirmUninstall
+	confirmForceUpgrade
+	confirmBatchUninstall
 )
 
 type harnessRowKind int
@@ -65,14 +71,18 @@ type Model struct {
 	cfg        *config.Config
 	configPath string
 
-	knownHarnesses map[string]struct{}
+	knownHarnesses   map[string]struct{}
+	projectHarnesses map[string]struct{}
+	workDir          string
 
 	registries []config.Registry
 	harnesses  []string
 
 	registrySkills     map[string][]scan.Skill
 	registrySyncStatus map[string]string
+	registryState      map[string]registrysync.RegistryState
 	harnessSkills      map[string][]scan.Skill
+	harnessStatus      map[string]upgrade.Status
 	harnessRows        []harnessRow
 
 	selectedRegistry   int
@@ -93,8 +103,31 @@ type Model struct {
 	pendingHarness    string
 	pendingSkillName  string
 
-	showConflict bool
-	pendingSkill scan.Skill
+	showConflict          bool
+	pendingSkill          scan.Skill
+	pendingInstallOptions install.Options
+
+	preview  *skillPreview
+	whatsNew *whatsNew
+	diff     *skillDiff
+	search   *globalSearch
+	filters  map[focusPane]string
+
+	syncJobs       map[string]*syncJob
+	syncGeneration int
+	installing     bool
+	syncSlots      chan struct{}
+	syncEvents     chan tea.Msg
+	spinner        spinner.Model
+
+	markedSkills   map[string]markedSkill
+	markedInstalls map[string]harnessRow
+	rangeAnchor    map[focusPane]int
+	pendingBatch   *batchInstall
+	picker         *harnessPicker
+	report         *batchReport
+
+	undo []undoAction
 
 	statusMessage string
 	errorMessage  string
@@ -112,25 +145,40 @@ func NewModel() (*Model, error) {
 	input.CharLimit = 4096
 	input.Width = 90
 
+	workDir, _ := os.Getwd()
+	if cfg.Offline {
+		registrysync.SetOffline(true)
+	}
+
 	m := &Model{
+		workDir:            workDir,
 		cfg:                cfg,
 		configPath:         configPath,
 		focus:              focusRegistries,
 		registrySkills:     map[string][]scan.Skill{},
 		registrySyncStatus: map[string]string{},
+		registryState:      map[string]registrysync.RegistryState{},
 		harnessSkills:      map[string][]scan.Skill{},
+		harnessStatus:      map[string]upgrade.Status{},
+		filters:            map[focusPane]string{},
+		markedSkills:       map[string]markedSkill{},
+		markedInstalls:     map[string]harnessRow{},
+		rangeAnchor:        map[focusPane]int{},
+		syncJobs:           map[string]*syncJob{},
+		syncSlots:          make(chan struct{}, syncWorkers),
+		syncEvents:         make(chan tea.Msg, syncWorkers),
+		spinner:            spinner.New(spinner.WithSpinner(spinner.MiniDot)),
 		input:              input,
 	}
 
 	m.refreshSources()
-	m.syncRemoteRegistriesOnStartup()
 	m.rescan()
 
 	return m, nil
 }
 
 func (m *Model) Init() tea.Cmd {
-	return nil
+	return tea.Batch(waitForSyncEvent(m.syncEvents), m.syncStaleRegistries(true), autoSyncTick())
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -138,8 +186,50 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = typed.Width
 		m.height = typed.Height
+		m.resizePreview()
+		return m, nil
+	case spinner.TickMsg:
+		if !m.syncing() {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(typed)
+		return m, cmd
+	case syncPhaseMsg:
+		return m, m.handleSyncPhase(typed)
+	case autoSyncMsg:
+		return m, tea.Batch(m.syncStaleRegistries(false), autoSyncTick())
+	case syncDoneMsg:
+		m.handleSyncDone(typed)
+		return m, nil
+	case installDoneMsg:
+		m.handleInstallDone(typed)
+		return m, nil
+	case batchInstallDoneMsg:
+		m.handleBatchInstallDone(typed)
+		return m, nil
+	case diffReadyMsg:
+		m.showDiff(typed.diff, typed.err)
 		return m, nil
 	case tea.KeyMsg:
+		if m.preview != nil {
+			return m.updatePreview(typed)
+		}
+		if m.whatsNew != nil {
+			return m.updateWhatsNew(typed)
+		}
+		if m.diff != nil {
+			return m.updateDiff(typed)
+		}
+		if m.search != nil {
+			return m.updateSearch(typed)
+		}
+		if m.report != nil {
+			return m.updateReport(typed)
+		}
+		if m.picker != nil {
+			return m.updatePicker(typed)
+		}
 		if m.showInput {
 			return m.updateInput(typed)
 		}
@@ -149,6 +239,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.showConflict {
 			return m.updateConflict(typed)
 		}
+		if m.pendingBatch != nil {
+			return m.updateBatchConflict(typed)
+		}
 		return m.updateNormal(typed)
 	}
 
@@ -156,6 +249,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.inputTarget == inputFilter {
+		return m.updateFilterInput(msg)
+	}
+
 	switch msg.String() {
 	case "esc":
 		m.resetInput()
@@ -202,6 +299,23 @@ func (m *Model) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m *Model) updateFilterInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.setFilter("")
+		m.resetInput()
+		return m, nil
+	case "enter":
+		m.resetInput()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.setFilter(strings.TrimSpace(m.input.Value()))
+	return m, cmd
+}
+
 func (m *Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "y":
@@ -229,12 +343,20 @@ func (m *Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.refreshSources()
 				m.rescan()
 			}
+		case confirmForceUpgrade:
+			m.upgradeSkill(m.harnessStatus[m.pendingPath], true)
+		case confirmBatchUninstall:
+			m.runBatchUninstall()
 		case confirmUninstall:
-			err := install.UninstallSkill(m.pendingHarness, m.pendingSkillName)
+			entry, err := install.UninstallSkill(m.pendingHarness, m.pendingSkillName, m.registries)
 			if err != nil {
 				m.errorMessage = err.Error()
 			} else {
+				m.pushUninstallUndo(entry)
 				m.statusMessage = "Uninstalled skill"
+				if entry.ID != "" {
+					m.statusMessage += " (z to undo)"
+				}
 				m.rescan()
 			}
 		}
@@ -257,6 +379,8 @@ func (m *Model) updateConflict(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "r":
 		m.installWithAction(install.ConflictRename)
 		return m, nil
+	case "d":
+		return m, m.diffPendingInstall()
 	case "s", "n", "esc":
 		m.showConflict = false
 		m.statusMessage = "Skipped install"
@@ -266,10 +390,22 @@ func (m *Model) updateConflict(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m *Model) updatePreview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "p", "q", "esc":
+		m.preview = nil
+		return m, nil
+	}
+
+	return m, m.preview.update(msg)
+}
+
 func (m *Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c", "q":
-		return m, tea.Quit
+		return m, m.quit()
 	case "tab", "right", "l":
 		m.focus = (m.focus + 1) % 3
 		return m, nil
@@ -289,18 +425,59 @@ func (m *Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.beginDeletePath()
 		return m, nil
 	case "i":
-		m.beginInstall()
+		return m, m.beginInstall()
+	case "I":
+		m.beginHarnessPicker()
 		return m, nil
 	case "u":
 		m.beginUninstall()
 		return m, nil
-	case "s":
-		m.syncSelectedRegistry(true)
-		m.rescan()
+	case "U":
+		m.beginUpgrade()
+		return m, nil
+	case "p":
+		m.beginPreview()
+		return m, nil
+	case "w":
+		m.beginWhatsNew()
+		return m, nil
+	case "D":
+		return m, m.beginDiff()
+	case "m":
+		m.cycleHarnessMode()
 		return m, nil
+	case "/":
+		m.beginFilter()
+		return m, nil
+	case "f":
+		m.beginSearch()
+		return m, nil
+	case "esc":
+		if !m.clearFilter() {
+			m.clearMarks()
+		}
+		return m, nil
+	case " ":
+		m.toggleMark()
+		return m, nil
+	case "V":
+		m.markRange()
+		return m, nil
+	case "A":
+		m.markAll()
+		return m, nil
+	case "s":
+		return m, m.syncSelectedRegistry()
 	case "S":
-		m.syncAllRemoteRegistries(true)
-		m.rescan()
+		return m, m.syncAllRemoteRegistries(true)
+	case "c":
+		m.cancelSelectedSync()
+		return m, nil
+	case "C":
+		m.cancelAllSyncs()
+		return m, nil
+	case "z":
+		m.undoLast()
 		return m, nil
 	case "r":
 		m.rescan()
@@ -313,15 +490,7 @@ func (m *Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) View() string {
-	width := m.width
-	if width <= 0 {
-		width = 120
-	}
-
-	height := m.height
-	if height <= 0 {
-		height = 36
-	}
+	width, height := m.viewSize()
 
 	paneWidth := (width - 4) / 3
 	if paneWidth < 30 {
@@ -331,11 +500,26 @@ func (m *Model) View() string {
 	header := m.renderHeader(width)
 	paneHeight := maxInt(8, height-3)
 
-	left := m.renderRegistriesPane(paneWidth, paneHeight)
-	middle := m.renderSkillsPane(paneWidth, paneHeight)
-	right := m.renderHarnessPane(paneWidth, paneHeight)
-
-	panes := lipgloss.JoinHorizontal(lipgloss.Top, left, middle, right)
+	var panes string
+	switch {
+	case m.preview != nil:
+		panes = m.renderPreviewPane(width-2, paneHeight)
+	case m.whatsNew != nil:
+		panes = m.renderWhatsNewPane(width-2, paneHeight)
+	case m.diff != nil:
+		panes = m.renderDiffPane(width-2, paneHeight)
+	case m.search != nil:
+		panes = m.renderSearchPane(width-2, paneHeight)
+	case m.report != nil:
+		panes = m.renderReportPane(width-2, paneHeight)
+	case m.picker != nil:
+		panes = m.renderPickerPane(width-2, paneHeight)
+	default:
+		left := m.renderRegistriesPane(paneWidth, paneHeight)
+		middle := m.renderSkillsPane(paneWidth, paneHeight)
+		right := m.renderHarnessPane(paneWidth, paneHeight)
+		panes = lipgloss.JoinHorizontal(lipgloss.Top, left, middle, right)
+	}
 
 	footer := m.renderFooter(width)
 	status := m.renderStatus(width)
@@ -350,27 +534,46 @@ func (m *Model) View() string {
 	return lipgloss.Place(width, height, lipgloss.Left, lipgloss.Top, frame)
 }
 
-func (m *Model) renderHeader(width int) string {
-	focus := "Registries"
-	switch m.focus {
-	case focusSkills:
-		focus = "Registry Skills"
-	case focusHarnesses:
-		focus = "Harness Installs"
+func (m *Model) viewSize() (int, int) {
+	width := m.width
+	if width <= 0 {
+		width = 120
+	}
+
+	height := m.height
+	if height <= 0 {
+		height = 36
 	}
 
-	text := fmt.Sprintf("skiller | focus: %s", focus)
+	return width, height
+}
+
+func (m *Model) renderHeader(width int) string {
+	text := fmt.Sprintf("skiller | focus: %s", focusTitle(m.focus))
+	if registrysync.Offline() {
+		text += " | offline"
+	}
+	if m.syncing() {
+		text += fmt.Sprintf(" | %s syncing %d registries (c cancel, C cancel all)", m.spinner.View(), len(m.syncJobs))
+	}
+	if m.preview != nil {
+		text = fmt.Sprintf("skiller | preview: %s", m.preview.skill.Name)
+	}
 	return headerStyle.Width(width).Render(truncate(text, width))
 }
 
 func (m *Model) renderRegistriesPane(width, height int) string {
-	title := paneTitleStyle(m.focus == focusRegistries).Render("Registries")
+	registries := m.visibleRegistries()
+	label := filterLabel("Registries", m.filters[focusRegistries], len(registries), len(m.registries))
+	title := paneTitleStyle(m.focus == focusRegistries).Render(truncate(label, width-4))
 
 	lines := []string{title}
 	if len(m.registries) == 0 {
 		lines = append(lines, mutedStyle.Render("No registries. Press a to add."))
+	} else if len(registries) == 0 {
+		lines = append(lines, mutedStyle.Render("No registries match the filter. Press esc to clear."))
 	} else {
-		for i, registry := range m.registries {
+		for i, registry := range registries {
 			status := m.registrySyncStatus[registry.ID]
 			if status == "" {
 				if registry.IsRemote() {
@@ -382,6 +585,13 @@ func (m *Model) renderRegistriesPane(width, height int) string {
 
 			label := fmt.Sprintf("[%s] %s", strings.ToUpper(string(registry.Type)), registry.DisplayName())
 			if registry.IsRemote() {
+				status = withCacheAge(status, m.registryState[registry.ID])
+				if commit := m.registryState[registry.ID].ShortCommit(); commit != "" {
+					status = status + " " + commit
+				}
+				if progress := m.syncStatusLabel(registry); progress != "" {
+					status = progress
+				}
 				label = label + " {" + status + "}"
 			}
 
@@ -398,11 +608,28 @@ func (m *Model) renderRegistriesPane(width, height int) string {
 	return paneBoxStyle(width, height, m.focus == focusRegistries).Render(strings.Join(lines, "\n"))
 }
 
+func withCacheAge(status string, state registrysync.RegistryState) string {
+	age := state.CachedAge(time.Now())
+	switch {
+	case age == "", status == "not synced", strings.HasPrefix(status, "done"):
+		return status
+	case status == "cached":
+		return "cached " + age
+	default:
+		return status + ", cached " + age
+	}
+}
+
 func (m *Model) renderSkillsPane(width, height int) string {
-	title := paneTitleStyle(m.focus == focusSkills).Render("Registry Skills")
+	registry, ok := m.selectedRegistryValue()
+	skills := m.skillsForSelectedRegistry()
+	label := filterLabel("Registry Skills", m.filters[focusSkills], len(skills), len(m.registrySkills[registry.ID]))
+	if len(m.markedSkills) > 0 {
+		label += fmt.Sprintf(" [%d marked]", len(m.markedSkills))
+	}
+	title := paneTitleStyle(m.focus == focusSkills).Render(truncate(label, width-4))
 	lines := []string{title}
 
-	registry, ok := m.selectedRegistryValue()
 	if !ok {
 		lines = append(lines, mutedStyle.Render("Select a registry to view skills."))
 		return paneBoxStyle(width, height, m.focus == focusSkills).Render(strings.Join(lines, "\n"))
@@ -413,44 +640,123 @@ func (m *Model) renderSkillsPane(width, height int) string {
 		source = source + "#" + registry.Ref
 	}
 	lines = append(lines, mutedStyle.Render(truncate("Source: "+source, width-2)))
+	if summary := m.registryState[registry.ID].Summary(); summary != "" && registry.IsRemote() {
+		lines = append(lines, mutedStyle.Render(truncate(strings.ToUpper(summary[:1])+summary[1:], width-2)))
+	}
+	if state := m.registryState[registry.ID]; state.LastError != "" && registry.IsRemote() {
+		failed := fmt.Sprintf("Last sync failed %s: %s", registrysync.FormatAge(time.Since(state.LastAttempt)), state.LastError)
+		lines = append(lines, mutedStyle.Render(truncate(failed, width-2)))
+	}
 
-	skills := m.registrySkills[registry.ID]
 	if len(skills) == 0 {
-		if registry.IsRemote() && m.registrySyncStatus[registry.ID] == "not synced" {
+		if len(m.registrySkills[registry.ID]) > 0 {
+			lines = append(lines, mutedStyle.Render("No skills match the filter. Press esc to clear."))
+		} else if progress := m.syncStatusLabel(registry); progress != "" {
+			lines = append(lines, mutedStyle.Render("Syncing: "+progress))
+		} else if registry.IsRemote() && m.registrySyncStatus[registry.ID] == "not synced" {
 			lines = append(lines, mutedStyle.Render("Remote cache missing. Press s to sync."))
 		} else {
 			lines = append(lines, mutedStyle.Render("No SKILL.md folders found."))
 		}
 	} else {
+		changes := m.registryState[registry.ID].Changes
+		root, _ := config.RegistryScanRoot(registry)
 		for i, skill := range skills {
-			line := skill.Name
-			if i == m.selectedSkill {
-				line = selectedStyle.Render("> " + line)
-			} else {
-				line = "  " + line
+			_, marked := m.markedSkills[skill.Path]
+			badge := ""
+			if relative, err := filepath.Rel(root, skill.Path); err == nil && registry.IsRemote() {
+				badge = changes.Status(filepath.ToSlash(relative))
 			}
-			lines = append(lines, truncate(line, width-2))
+			lines = append(lines, renderSkillLine(skill, i == m.selectedSkill, marked, badge, width-2))
 		}
 	}
 
 	return paneBoxStyle(width, height, m.focus == focusSkills).Render(strings.Join(lines, "\n"))
 }
 
+func renderSkillLine(skill scan.Skill, selected, marked bool, badge string, width int) string {
+	detail := skill.Metadata.Description
+	if skill.MetadataErr != nil {
+		detail = "invalid frontmatter"
+	}
+	detail = strings.Join(strings.Fields(detail), " ")
+
+	prefix := "  "
+	if selected {
+		prefix = "> "
+	}
+	if marked {
+		prefix = prefix[:1] + "*"
+	}
+
+	name := truncate(prefix+skill.Name, width)
+	tag := ""
+	if badge != "" {
+		tag = " [" + badge + "]"
+		if len([]rune(name))+len(tag) > width {
+			tag = ""
+		}
+	}
+	remaining := width - len([]rune(name)) - len(tag) - 2
+	if detail == "" || remaining < 4 {
+		if selected {
+			return selectedStyle.Render(name + tag)
+		}
+		return name + badgeStyle.Render(tag)
+	}
+
+	detail = truncate(detail, remaining)
+	if selected {
+		return selectedStyle.Render(name + tag + "  " + detail)
+	}
+	return name + badgeStyle.Render(tag) + "  " + mutedStyle.Render(detail)
+}
+
 func (m *Model) renderHarnessPane(width, height int) string {
-	title := paneTitleStyle(m.focus == focusHarnesses).Render("Harness Installs")
+	rows := m.visibleHarnessRows()
+	label := filterLabel("Harness Installs", m.filters[focusHarnesses], countSkillRows(rows), countSkillRows(m.harnessRows))
+	if len(m.markedInstalls) > 0 {
+		label += fmt.Sprintf(" [%d marked]", len(m.markedInstalls))
+	}
+	title := paneTitleStyle(m.focus == focusHarnesses).Render(truncate(label, width-4))
 	lines := []string{title}
 
 	if len(m.harnessRows) == 0 {
 		lines = append(lines, mutedStyle.Render("No harness paths. Press a to add."))
 		return paneBoxStyle(width, height, m.focus == focusHarnesses).Render(strings.Join(lines, "\n"))
 	}
+	if len(rows) == 0 {
+		lines = append(lines, mutedStyle.Render("No installed skills match the filter. Press esc to clear."))
+		return paneBoxStyle(width, height, m.focus == focusHarnesses).Render(strings.Join(lines, "\n"))
+	}
 
-	for i, row := range m.harnessRows {
+	inProject := false
+	for i, row := range rows {
 		var line string
 		if row.kind == harnessRowHeader {
-			line = fmt.Sprintf("[%s]", row.harness)
+			if _, ok := m.projectHarnesses[row.harness]; ok && !inProject {
+				inProject = true
+				lines = append(lines, mutedStyle.Render(truncate(m.projectGroupLabel(), width-2)))
+			}
+			line = fmt.Sprintf("[%s]", m.harnessLabel(row.harness))
+			if mode := m.cfg.HarnessMode(row.harness); mode != config.InstallModeCopy {
+				line += " (" + string(mode) + ")"
+			}
 		} else {
 			line = "  - " + row.skill.Name
+			if _, marked := m.markedInstalls[row.skill.Path]; marked {
+				line = "  * " + row.skill.Name
+			}
+			if row.skill.Link != "" {
+				line += " -> " + row.skill.Link
+			} else if row.skill.Adapted {
+				line += " [adapted] " + filepath.Base(row.skill.Path)
+			} else if status, ok := m.harnessStatus[row.skill.Path]; ok && status.State != upgrade.StateUnmanaged {
+				if status.State != upgrade.StateUpToDate {
+					line += " [" + string(status.State) + "]"
+				}
+				line += " <- " + status.Record.Summary()
+			}
 		}
 
 		if i == m.selectedHarnessRow {
@@ -465,8 +771,29 @@ func (m *Model) renderHarnessPane(width, height int) string {
 	return paneBoxStyle(width, height, m.focus == focusHarnesses).Render(strings.Join(lines, "\n"))
 }
 
+func (m *Model) renderPreviewPane(width, height int) string {
+	title := paneTitleStyle(true).Render("Preview: " + m.preview.skill.Name)
+	position := fmt.Sprintf("%3.0f%%", m.preview.viewport.ScrollPercent()*100)
+	lines := []string{title, m.preview.viewport.View(), mutedStyle.Render(position)}
+	return paneBoxStyle(width, height, true).Render(strings.Join(lines, "\n"))
+}
+
 func (m *Model) renderFooter(width int) string {
-	text := "Nav: arrows/hjkl | pane: h/l/tab | a add path/url | d delete | i install | u uninstall | s sync one | S sync all | r rescan | q quit"
+	text := "Nav: arrows/hjkl | pane: h/l/tab | / filter | f find | space mark | V range | A mark all | a add path/url | d delete | i install | I install into... | u uninstall | U upgrade | p preview | D diff | w what's new | m install mode | s sync one | S sync all | c/C cancel sync | z undo | r rescan | q quit"
+	switch {
+	case m.preview != nil:
+		text = "Preview: j/k scroll | d/u half page | f/b page | g/G top/bottom | h/l scroll sideways | p/esc close"
+	case m.whatsNew != nil:
+		text = "What's new: j/k scroll | d/u half page | f/b page | w/esc close"
+	case m.diff != nil:
+		text = "Diff: j/k scroll | d/u half page | f/b page | g/G top/bottom | D/esc close"
+	case m.search != nil:
+		text = "Search: type to match name, description or tags | up/down select | enter jump | esc close"
+	case m.report != nil:
+		text = "Report: j/k scroll | enter/esc close"
+	case m.picker != nil:
+		text = "Harnesses: j/k move | space toggle | a toggle all | enter install | esc cancel"
+	}
 	return helpStyle.Width(width).Render(truncate(text, width))
 }
 
@@ -482,13 +809,20 @@ func (m *Model) renderStatus(width int) string {
 
 func (m *Model) renderOverlay(width int) string {
 	switch {
+	case m.showInput && m.inputTarget == inputFilter:
+		prompt := fmt.Sprintf("%s: /%s", m.inputPrompt, m.input.View())
+		return overlayStyle.Width(width).Render(prompt + "  [enter keep, esc clear]")
 	case m.showInput:
 		prompt := fmt.Sprintf("%s: %s", m.inputPrompt, m.input.View())
 		return overlayStyle.Width(width).Render(prompt + "  [enter save, esc cancel]")
 	case m.showConfirm:
 		return overlayStyle.Width(width).Render(m.confirmMessage + "  [y/n]")
 	case m.showConflict:
-		message := "Skill already exists in target harness. [o] overwrite  [r] rename  [s] skip"
+		message := "Skill already exists in target harness. [d] view diff  [o] overwrite  [r] rename  [s] skip"
+		return overlayStyle.Width(width).Render(message)
+	case m.pendingBatch != nil:
+		message := fmt.Sprintf("%d of %d installs conflict in %s. Apply to all: [o] overwrite  [r] rename  [s] skip  [esc] cancel",
+			m.pendingBatch.conflicts, len(m.pendingBatch.items)*len(m.pendingBatch.harnesses), m.pendingBatch.target())
 		return overlayStyle.Width(width).Render(message)
 	default:
 		return ""
@@ -501,7 +835,7 @@ func (m *Model) beginAddPath() {
 
 	switch m.focus {
 	case focusRegistries:
-		m.inputPrompt = "Add registry path or git URL (append #ref optional)"
+		m.inputPrompt = "Add registry path, archive, index or git URL (append #ref, #ref:subdir or #:subdir optional)"
 		m.inputTarget = inputRegistry
 	case focusHarnesses:
 		m.inputPrompt = "Add harness path"
@@ -550,43 +884,180 @@ func (m *Model) beginDeletePath() {
 	}
 }
 
-func (m *Model) beginInstall() {
+type installDoneMsg struct {
+	skill   scan.Skill
+	harness string
+	opts    install.Options
+	ask     bool
+	result  install.InstallResult
+	err     error
+}
+
+func (m *Model) beginInstall() tea.Cmd {
 	m.errorMessage = ""
 	m.statusMessage = ""
 
+	if m.installing {
+		m.statusMessage = "An install is already running"
+		return nil
+	}
+	if len(m.markedSkills) > 0 {
+		return m.beginBatchInstall()
+	}
+
 	skill, ok := m.selectedRegistrySkill()
 	if !ok {
 		m.statusMessage = "No skill selected"
-		return
+		return nil
 	}
 
 	harness := m.selectedHarnessPath()
 	if harness == "" {
 		m.statusMessage = "No harness selected"
-		return
+		return nil
 	}
 
-	result, err := install.InstallSkill(skill.Path, harness, install.ConflictSkip)
-	if err != nil {
-		m.errorMessage = err.Error()
+	registry, _ := m.selectedRegistryValue()
+	opts := install.Options{Mode: m.cfg.HarnessMode(harness), Format: m.cfg.HarnessFormat(harness)}
+	action, ask := install.ConflictSkip, true
+	if policy := m.cfg.HarnessConflict(harness); policy != config.ConflictPolicyAsk {
+		action, ask = install.ConflictAction(policy), false
+	}
+
+	m.installing = true
+	m.statusMessage = fmt.Sprintf("Installing %s...", skill.Name)
+	return func() tea.Msg {
+		done := installDoneMsg{skill: skill, harness: harness, opts: opts, ask: ask}
+		if done.err = registrysync.FetchSkill(registry, skill.Path); done.err != nil {
+			return done
+		}
+		record, err := provenance.ForSkill(registry, skill)
+		if err != nil {
+			done.err = err
+			return done
+		}
+		done.opts.Provenance = &record
+		done.result, done.err = install.InstallSkillWithOptions(skill.Path, harness, action, done.opts)
+		return done
+	}
+}
+
+func (m *Model) handleInstallDone(msg installDoneMsg) {
+	m.installing = false
+	m.statusMessage = ""
+	if msg.err != nil {
+		m.errorMessage = msg.err.Error()
 		return
 	}
 
-	if result.Conflict {
-		m.pendingSkill = skill
-		m.pendingHarness = harness
+	if msg.ask && msg.result.Conflict {
+		m.pendingSkill = msg.skill
+		m.pendingHarness = msg.harness
+		m.pendingInstallOptions = msg.opts
 		m.showConflict = true
 		return
 	}
+	m.finishInstall(msg.result)
+}
+
+func (m *Model) beginPreview() {
+	m.errorMessage = ""
+	m.statusMessage = ""
+
+	switch m.focus {
+	case focusSkills:
+		skill, ok := m.selectedRegistrySkill()
+		if !ok {
+			m.statusMessage = "No skill selected"
+			return
+		}
+		registry, _ := m.selectedRegistryValue()
+		origin := registry.DisplayName()
+		if registry.Ref != "" {
+			origin += "#" + registry.Ref
+		}
+		m.preview = newSkillPreview(skill, origin)
+	case focusHarnesses:
+		row, ok := m.selectedHarnessRowValue()
+		if !ok || row.kind != harnessRowSkill {
+			m.statusMessage = "Select an installed skill to preview"
+			return
+		}
+		if row.skill.Adapted {
+			m.statusMessage = "Adapted installs have no SKILL.md to preview"
+			return
+		}
+		origin := "installed in " + row.harness
+		if row.skill.Link != "" {
+			origin += " -> " + row.skill.Link
+		} else if status, ok := m.harnessStatus[row.skill.Path]; ok && status.State != upgrade.StateUnmanaged {
+			origin += " <- " + status.Record.Summary()
+		}
+		m.preview = newSkillPreview(row.skill, origin)
+	default:
+		m.statusMessage = "Select a registry skill or installed skill to preview"
+		return
+	}
 
-	m.statusMessage = fmt.Sprintf("Installed %s", result.Name)
-	m.rescan()
+	m.resizePreview()
+}
+
+func (m *Model) resizePreview() {
+	width, height := m.viewSize()
+	paneHeight := maxInt(8, height-3)
+	if m.preview != nil {
+		m.preview.setSize(maxInt(10, width-4), maxInt(1, paneHeight-2))
+	}
+	if m.whatsNew != nil {
+		m.whatsNew.setSize(maxInt(10, width-4), maxInt(1, paneHeight-3))
+	}
+	if m.diff != nil {
+		m.diff.setSize(maxInt(10, width-4), maxInt(1, paneHeight-2))
+	}
+}
+
+func (m *Model) cycleHarnessMode() {
+	m.errorMessage = ""
+	m.statusMessage = ""
+
+	if m.focus != focusHarnesses {
+		m.statusMessage = "Switch to Harness Installs pane to change a harness install mode"
+		return
+	}
+	harness := m.selectedHarnessPath()
+	if harness == "" {
+		m.statusMessage = "No harness selected"
+		return
+	}
+
+	next := config.InstallModes[0]
+	current := m.cfg.HarnessMode(harness)
+	for i, mode := range config.InstallModes {
+		if mode == current {
+			next = config.InstallModes[(i+1)%len(config.InstallModes)]
+		}
+	}
+
+	if err := m.cfg.SetHarnessMode(harness, next); err != nil {
+		m.errorMessage = err.Error()
+		return
+	}
+	if err := m.saveConfig(); err != nil {
+		m.errorMessage = err.Error()
+		return
+	}
+	m.statusMessage = fmt.Sprintf("New installs into %s use %s", harness, next)
 }
 
 func (m *Model) beginUninstall() {
 	m.errorMessage = ""
 	m.statusMessage = ""
 
+	if len(m.markedInstalls) > 0 {
+		m.beginBatchUninstall()
+		return
+	}
+
 	row, ok := m.selectedHarnessRowValue()
 	if !ok || row.kind != harnessRowSkill {
 		m.statusMessage = "Select an installed skill in Harness Installs pane"
@@ -600,105 +1071,124 @@ func (m *Model) beginUninstall() {
 	m.confirmMessage = fmt.Sprintf("Uninstall %s from %s?", row.skill.Name, row.harness)
 }
 
-func (m *Model) installWithAction(action install.ConflictAction) {
-	result, err := install.InstallSkill(m.pendingSkill.Path, m.pendingHarness, action)
-	if err != nil {
-		m.errorMessage = err.Error()
-		m.showConflict = false
+func (m *Model) beginUpgrade() {
+	m.errorMessage = ""
+	m.statusMessage = ""
+
+	row, ok := m.selectedHarnessRowValue()
+	if !ok {
+		m.statusMessage = "No harness selected"
 		return
 	}
 
-	m.showConflict = false
-	if !result.Installed {
-		m.statusMessage = "Skipped install"
+	if row.kind == harnessRowHeader {
+		m.upgradeHarness(row.harness)
 		return
 	}
 
-	if result.Renamed {
-		m.statusMessage = fmt.Sprintf("Installed as %s", result.Name)
-	} else {
-		m.statusMessage = fmt.Sprintf("Installed %s", result.Name)
+	status := m.harnessStatus[row.skill.Path]
+	switch status.State {
+	case upgrade.StateOutdated:
+		m.upgradeSkill(status, false)
+	case upgrade.StateModified:
+		m.showConfirm = true
+		m.confirmKind = confirmForceUpgrade
+		m.pendingPath = row.skill.Path
+		m.confirmMessage = fmt.Sprintf("%s has local modifications. Overwrite with registry version?", row.skill.Name)
+	case upgrade.StateUpToDate:
+		m.statusMessage = fmt.Sprintf("%s is up to date", row.skill.Name)
+	case "":
+		m.statusMessage = fmt.Sprintf("Could not determine status of %s", row.skill.Name)
+	default:
+		m.statusMessage = fmt.Sprintf("%s is %s and cannot be upgraded", row.skill.Name, status.State)
 	}
-
-	m.rescan()
 }
 
-func (m *Model) syncSelectedRegistry(interactive bool) {
-	registry, ok := m.selectedRegistryValue()
-	if !ok {
-		m.statusMessage = "No registry selected"
+func (m *Model) upgradeSkill(status upgrade.Status, force bool) {
+	result, err := upgrade.Upgrade(status, force)
+	if err != nil {
+		m.errorMessage = err.Error()
 		return
 	}
-
-	if !registry.IsRemote() {
-		m.statusMessage = "Selected registry is local"
-		return
+	if result.Trashed != nil {
+		m.pushUndo(*result.Trashed, true)
 	}
 
-	if m.syncRegistry(registry, interactive) {
-		m.statusMessage = fmt.Sprintf("Synced %s", registry.DisplayName())
-	}
+	m.statusMessage = fmt.Sprintf("Upgraded %s", status.Installed.Name)
+	m.rescan()
 }
 
-func (m *Model) syncAllRemoteRegistries(interactive bool) {
-	successCount := 0
-	totalCount := 0
-
-	for _, registry := range m.registries {
-		if !registry.IsRemote() {
-			continue
-		}
-		totalCount++
-		if m.syncRegistry(registry, interactive) {
-			successCount++
+func (m *Model) upgradeHarness(harness string) {
+	upgraded, modified := 0, 0
+	for _, skill := range m.harnessSkills[harness] {
+		status := m.harnessStatus[skill.Path]
+		switch status.State {
+		case upgrade.StateOutdated:
+			result, err := upgrade.Upgrade(status, false)
+			if err != nil {
+				m.errorMessage = err.Error()
+				continue
+			}
+			if result.Trashed != nil {
+				m.pushUndo(*result.Trashed, true)
+			}
+			upgraded++
+		case upgrade.StateModified:
+			modified++
 		}
 	}
 
-	if totalCount == 0 {
-		m.statusMessage = "No remote registries configured"
+	message := fmt.Sprintf("Upgraded %d skills in %s", upgraded, harness)
+	if modified > 0 {
+		message += fmt.Sprintf(" (%d locally modified, select them to force)", modified)
+	}
+	m.statusMessage = message
+	m.rescan()
+}
+
+func (m *Model) installWithAction(action install.ConflictAction) {
+	result, err := install.InstallSkillWithOptions(m.pendingSkill.Path, m.pendingHarness, action, m.pendingInstallOptions)
+	if err != nil {
+		m.errorMessage = err.Error()
+		m.showConflict = false
 		return
 	}
 
-	m.statusMessage = fmt.Sprintf("Synced %d/%d remote registries", successCount, totalCount)
+	m.showConflict = false
+	m.finishInstall(result)
 }
 
-func (m *Model) syncRemoteRegistriesOnStartup() {
-	for _, registry := range m.registries {
-		if !registry.IsRemote() {
-			continue
-		}
-		m.syncRegistry(registry, false)
+func (m *Model) finishInstall(result install.InstallResult) {
+	if !result.Installed {
+		m.statusMessage = "Skipped install"
+		return
 	}
-}
 
-func (m *Model) syncRegistry(registry config.Registry, interactive bool) bool {
-	timeout := 20 * time.Second
-	if interactive {
-		timeout = 4 * time.Minute
+	switch {
+	case result.Trashed != nil:
+		m.pushUndo(*result.Trashed, true)
+		m.statusMessage = fmt.Sprintf("Overwrote %s (z to undo)", result.Name)
+	case result.Renamed:
+		m.statusMessage = fmt.Sprintf("Installed as %s", result.Name)
+	default:
+		m.statusMessage = fmt.Sprintf("Installed %s", result.Name)
 	}
 
-	_, err := registrysync.SyncRegistry(registry, interactive, timeout)
-	if err != nil {
-		if registrysync.IsAuthError(err) {
-			m.registrySyncStatus[registry.ID] = "auth required"
-			if interactive {
-				m.errorMessage = "Authentication required. Ensure credentials are available and retry sync."
-			}
-			return false
-		}
+	m.rescan()
+}
 
-		m.registrySyncStatus[registry.ID] = "error"
-		if interactive {
-			m.errorMessage = err.Error()
-		}
-		return false
+func (m *Model) projectGroupLabel() string {
+	if root, ok := config.ProjectRoot(m.workDir); ok {
+		return "Project " + root
 	}
+	return "Project " + m.workDir
+}
 
-	m.registrySyncStatus[registry.ID] = "synced"
-	if interactive {
-		m.errorMessage = ""
+func (m *Model) harnessLabel(harness string) string {
+	if profile, ok := m.cfg.Harness(harness); ok && profile.Name != "" {
+		return profile.Name + ": " + harness
 	}
-	return true
+	return harness
 }
 
 func (m *Model) saveConfig() error {
@@ -708,14 +1198,34 @@ func (m *Model) saveConfig() error {
 func (m *Model) refreshSources() {
 	m.registries = append([]config.Registry(nil), m.cfg.Registries...)
 
-	detected := config.DetectKnownHarnesses()
-	m.harnesses = config.MergeUnique(m.cfg.Harnesses, detected)
+	detected := m.cfg.DetectKnownHarnesses()
 
 	m.knownHarnesses = map[string]struct{}{}
 	for _, known := range detected {
 		m.knownHarnesses[known] = struct{}{}
 	}
 
+	var project []string
+	m.projectHarnesses = map[string]struct{}{}
+	if m.workDir != "" {
+		for _, harness := range m.cfg.DetectProjectHarnesses(m.workDir) {
+			if m.cfg.HarnessEnabled(harness) {
+				project = append(project, harness)
+				m.projectHarnesses[harness] = struct{}{}
+			}
+		}
+	}
+
+	m.harnesses = nil
+	for _, harness := range m.cfg.ActiveHarnesses() {
+		if _, ok := m.projectHarnesses[harness]; !ok {
+			m.harnesses = append(m.harnesses, harness)
+		}
+	}
+	sort.Strings(m.harnesses)
+	sort.Strings(project)
+	m.harnesses = append(m.harnesses, project...)
+
 	sort.Slice(m.registries, func(i, j int) bool {
 		if m.registries[i].Type == m.registries[j].Type {
 			if m.registries[i].DisplayName() == m.registries[j].DisplayName() {
@@ -725,7 +1235,6 @@ func (m *Model) refreshSources() {
 		}
 		return m.registries[i].Type < m.registries[j].Type
 	})
-	sort.Strings(m.harnesses)
 
 	if m.selectedRegistry >= len(m.registries) {
 		m.selectedRegistry = maxInt(0, len(m.registries)-1)
@@ -736,6 +1245,13 @@ func (m *Model) rescan() {
 	m.registrySkills = map[string][]scan.Skill{}
 	m.harnessSkills = map[string][]scan.Skill{}
 
+	if state, err := registrysync.LoadState(); err == nil {
+		m.registryState = state.Registries
+	} else {
+		m.errorMessage = err.Error()
+	}
+	m.harnessStatus = map[string]upgrade.Status{}
+
 	for _, registry := range m.registries {
 		scanRoot, scanStatus, err := m.registryScanRoot(registry)
 		if scanStatus != "" {
@@ -761,16 +1277,29 @@ func (m *Model) rescan() {
 		m.registrySkills[registry.ID] = skills
 	}
 
+	checker := upgrade.NewChecker(m.registries, m.registrySkills)
 	for _, harness := range m.harnesses {
+		if profile, ok := m.cfg.Harness(harness); ok && profile.Problem != "" {
+			m.errorMessage = profile.Problem
+		}
 		skills, err := scan.ScanHarness(harness)
 		if err != nil {
 			m.errorMessage = err.Error()
 			continue
 		}
 		m.harnessSkills[harness] = skills
+
+		for _, skill := range skills {
+			status, err := checker.Check(harness, skill)
+			if err != nil {
+				continue
+			}
+			m.harnessStatus[skill.Path] = status
+		}
 	}
 
 	m.rebuildHarnessRows()
+	m.pruneMarks()
 	m.clampSelections()
 }
 
@@ -779,19 +1308,17 @@ func (m *Model) registryScanRoot(registry config.Registry) (string, string, erro
 		return registry.Source, "ready", nil
 	}
 
-	repoPath, err := config.RegistryCachePath(registry)
+	root, err := config.RegistryScanRoot(registry)
 	if err != nil {
 		return "", "error", err
 	}
 
-	root := repoPath
-	if strings.TrimSpace(registry.Subdir) != "" {
-		root = filepath.Join(root, registry.Subdir)
-	}
-
 	info, err := os.Stat(root)
 	if err != nil {
 		if os.IsNotExist(err) {
+			if status := m.registrySyncStatus[registry.ID]; status != "" && status != "cached" {
+				return "", "", nil
+			}
 			return "", "not synced", nil
 		}
 		return "", "error", err
@@ -819,9 +1346,19 @@ func (m *Model) rebuildHarnessRows() {
 	m.harnessRows = rows
 }
 
+func countSkillRows(rows []harnessRow) int {
+	count := 0
+	for _, row := range rows {
+		if row.kind == harnessRowSkill {
+			count++
+		}
+	}
+	return count
+}
+
 func (m *Model) clampSelections() {
-	if m.selectedRegistry >= len(m.registries) {
-		m.selectedRegistry = maxInt(0, len(m.registries)-1)
+	if registries := m.visibleRegistries(); m.selectedRegistry >= len(registries) {
+		m.selectedRegistry = maxInt(0, len(registries)-1)
 	}
 
 	skills := m.skillsForSelectedRegistry()
@@ -829,8 +1366,8 @@ func (m *Model) clampSelections() {
 		m.selectedSkill = maxInt(0, len(skills)-1)
 	}
 
-	if m.selectedHarnessRow >= len(m.harnessRows) {
-		m.selectedHarnessRow = maxInt(0, len(m.harnessRows)-1)
+	if rows := m.visibleHarnessRows(); m.selectedHarnessRow >= len(rows) {
+		m.selectedHarnessRow = maxInt(0, len(rows)-1)
 	}
 	if m.selectedHarnessRow < 0 {
 		m.selectedHarnessRow = 0
@@ -840,11 +1377,12 @@ func (m *Model) clampSelections() {
 func (m *Model) moveSelection(delta int) {
 	switch m.focus {
 	case focusRegistries:
-		if len(m.registries) == 0 {
+		registries := m.visibleRegistries()
+		if len(registries) == 0 {
 			return
 		}
 		previous := m.selectedRegistry
-		m.selectedRegistry = clamp(m.selectedRegistry+delta, 0, len(m.registries)-1)
+		m.selectedRegistry = clamp(m.selectedRegistry+delta, 0, len(registries)-1)
 		if m.selectedRegistry != previous {
 			m.selectedSkill = 0
 		}
@@ -855,21 +1393,23 @@ func (m *Model) moveSelection(delta int) {
 		}
 		m.selectedSkill = clamp(m.selectedSkill+delta, 0, len(skills)-1)
 	case focusHarnesses:
-		if len(m.harnessRows) == 0 {
+		rows := m.visibleHarnessRows()
+		if len(rows) == 0 {
 			return
 		}
-		m.selectedHarnessRow = clamp(m.selectedHarnessRow+delta, 0, len(m.harnessRows)-1)
+		m.selectedHarnessRow = clamp(m.selectedHarnessRow+delta, 0, len(rows)-1)
 	}
 }
 
 func (m *Model) selectedRegistryValue() (config.Registry, bool) {
-	if len(m.registries) == 0 {
+	registries := m.visibleRegistries()
+	if len(registries) == 0 {
 		return config.Registry{}, false
 	}
-	if m.selectedRegistry < 0 || m.selectedRegistry >= len(m.registries) {
+	if m.selectedRegistry < 0 || m.selectedRegistry >= len(registries) {
 		return config.Registry{}, false
 	}
-	return m.registries[m.selectedRegistry], true
+	return registries[m.selectedRegistry], true
 }
 
 func (m *Model) registryByID(id string) (config.Registry, bool) {
@@ -886,7 +1426,7 @@ func (m *Model) skillsForSelectedRegistry() []scan.Skill {
 	if !ok {
 		return []scan.Skill{}
 	}
-	return m.registrySkills[registry.ID]
+	return filterSkills(m.registrySkills[registry.ID], m.filters[focusSkills])
 }
 
 func (m *Model) selectedRegistrySkill() (scan.Skill, bool) {
@@ -901,13 +1441,14 @@ func (m *Model) selectedRegistrySkill() (scan.Skill, bool) {
 }
 
 func (m *Model) selectedHarnessRowValue() (harnessRow, bool) {
-	if len(m.harnessRows) == 0 {
+	rows := m.visibleHarnessRows()
+	if len(rows) == 0 {
 		return harnessRow{}, false
 	}
-	if m.selectedHarnessRow < 0 || m.selectedHarnessRow >= len(m.harnessRows) {
+	if m.selectedHarnessRow < 0 || m.selectedHarnessRow >= len(rows) {
 		return harnessRow{}, false
 	}
-	return m.harnessRows[m.selectedHarnessRow], true
+	return rows[m.selectedHarnessRow], true
 }
 
 func (m *Model) selectedHarnessPath() string {
@@ -976,6 +1517,8 @@ var (
 
 	mutedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
 
+	badgeStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214"))
+
 	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("231")).Background(lipgloss.Color("31"))
 
 	helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("249"))
@@ -985,6 +1528,16 @@ var (
 	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
 
 	overlayStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("229")).Background(lipgloss.Color("24")).Padding(0, 1)
+
+	titleStyle = lipgloss.NewStyle().Bold(true).Underline(true).Foreground(lipgloss.Color("45"))
+
+	headingStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("75"))
+
+	codeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("186"))
+
+	inlineCodeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("186")).Background(lipgloss.Color("236"))
+
+	linkStyle = lipgloss.NewStyle().Underline(true).Foreground(lipgloss.Color("39"))
 )
 
 func paneTitleStyle(active bool) lipgloss.Style {
@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"skiller/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type harnessPicker struct {
+	items    []markedSkill
+	checked  map[string]bool
+	selected int
+}
+
+func (m *Model) beginHarnessPicker() {
+	m.errorMessage = ""
+	m.statusMessage = ""
+
+	items := m.markedItems()
+	if len(items) == 0 {
+		skill, ok := m.selectedRegistrySkill()
+		if !ok {
+			m.statusMessage = "No skill selected"
+			return
+		}
+		registry, _ := m.selectedRegistryValue()
+		items = []markedSkill{{registry: registry, skill: skill}}
+	}
+	if len(m.harnesses) == 0 {
+		m.statusMessage = "No harness paths. Press a in Harness Installs to add one."
+		return
+	}
+
+	picker := &harnessPicker{items: items, checked: map[string]bool{}}
+	if harness := m.selectedHarnessPath(); harness != "" {
+		picker.checked[harness] = true
+		for i, candidate := range m.harnesses {
+			if candidate == harness {
+				picker.selected = i
+			}
+		}
+	}
+	m.picker = picker
+}
+
+func (m *Model) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	picker := m.picker
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "esc", "q":
+		m.picker = nil
+		m.statusMessage = "Cancelled install"
+	case "up", "k":
+		picker.selected = clamp(picker.selected-1, 0, len(m.harnesses)-1)
+	case "down", "j":
+		picker.selected = clamp(picker.selected+1, 0, len(m.harnesses)-1)
+	case " ", "x":
+		harness := m.harnesses[picker.selected]
+		picker.checked[harness] = !picker.checked[harness]
+	case "a":
+		all := len(picker.chosen(m.harnesses)) == len(m.harnesses)
+		for _, harness := range m.harnesses {
+			picker.checked[harness] = !all
+		}
+	case "enter":
+		harnesses := picker.chosen(m.harnesses)
+		if len(harnesses) == 0 {
+			m.statusMessage = "Check at least one harness with space"
+			return m, nil
+		}
+		m.picker = nil
+		return m, m.startBatchInstall(harnesses, picker.items)
+	}
+	return m, nil
+}
+
+func (p *harnessPicker) chosen(harnesses []string) []string {
+	var chosen []string
+	for _, harness := range harnesses {
+		if p.checked[harness] {
+			chosen = append(chosen, harness)
+		}
+	}
+	return chosen
+}
+
+func (m *Model) renderPickerPane(width, height int) string {
+	picker := m.picker
+	subject := picker.items[0].skill.Name
+	if len(picker.items) > 1 {
+		subject = fmt.Sprintf("%d marked skills", len(picker.items))
+	}
+	title := paneTitleStyle(true).Render(truncate("Install "+subject+" into", width-4))
+	lines := []string{title}
+
+	for i, harness := range m.harnesses {
+		box := "[ ]"
+		if picker.checked[harness] {
+			box = "[x]"
+		}
+		line := box + " " + m.harnessLabel(harness)
+		if mode := m.cfg.HarnessMode(harness); mode != config.InstallModeCopy {
+			line += " (" + string(mode) + ")"
+		}
+		if i == picker.selected {
+			line = selectedStyle.Render(truncate("> "+line, width-2))
+		} else {
+			line = truncate("  "+line, width-2)
+		}
+		lines = append(lines, line)
+	}
+
+	return paneBoxStyle(width, height, true).Render(strings.Join(lines, "\n"))
+}
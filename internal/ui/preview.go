@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"skiller/internal/provenance"
+	"skiller/internal/scan"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type previewFile struct {
+	path   string
+	size   int64
+	target string
+}
+
+type skillPreview struct {
+	skill    scan.Skill
+	origin   string
+	body     string
+	readErr  error
+	files    []previewFile
+	filesErr error
+
+	viewport viewport.Model
+	width    int
+}
+
+func newSkillPreview(skill scan.Skill, origin string) *skillPreview {
+	preview := &skillPreview{skill: skill, origin: origin}
+
+	data, err := os.ReadFile(filepath.Join(skill.Path, scan.MarkerFileName))
+	if err != nil {
+		preview.readErr = err
+	} else {
+		_, preview.body, _ = scan.ParseFrontmatter(data)
+	}
+
+	preview.files, preview.filesErr = listSkillFiles(skill.Path)
+	preview.viewport = viewport.New(0, 0)
+	return preview
+}
+
+func (p *skillPreview) setSize(width, height int) {
+	p.viewport.Width = width
+	p.viewport.Height = height
+	if width == p.width {
+		return
+	}
+
+	p.width = width
+	offset := p.viewport.YOffset
+	p.viewport.SetContent(strings.Join(p.render(width), "\n"))
+	p.viewport.SetYOffset(offset)
+}
+
+func (p *skillPreview) render(width int) []string {
+	meta := p.skill.Metadata
+
+	var lines []string
+	if meta.Description != "" {
+		lines = append(lines, wrapMarkdown(&markdownBlock{text: meta.Description}, width)...)
+	}
+
+	fields := []struct{ label, value string }{
+		{"Version", meta.Version},
+		{"License", meta.License},
+		{"Tags", strings.Join(meta.Tags, ", ")},
+		{"Allowed tools", strings.Join(meta.AllowedTools, ", ")},
+		{"Source", p.origin},
+		{"Path", p.skill.Path},
+	}
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		lines = append(lines, wrapMarkdown(&markdownBlock{
+			text:   field.value,
+			prefix: field.label + ": ",
+			hang:   strings.Repeat(" ", len(field.label)+2),
+			style:  mutedStyle,
+		}, width)...)
+	}
+	if p.skill.MetadataErr != nil {
+		lines = append(lines, errorStyle.Render(truncate("Invalid frontmatter: "+p.skill.MetadataErr.Error(), width)))
+	}
+
+	lines = append(lines, mutedStyle.Render(strings.Repeat("─", width)))
+	switch {
+	case p.readErr != nil:
+		lines = append(lines, errorStyle.Render(truncate(p.readErr.Error(), width)))
+	case strings.TrimSpace(p.body) == "":
+		lines = append(lines, mutedStyle.Render(scan.MarkerFileName+" has no content."))
+	default:
+		lines = append(lines, renderMarkdown(p.body, width)...)
+	}
+
+	lines = append(lines, "", mutedStyle.Render(strings.Repeat("─", width)))
+	lines = append(lines, headingStyle.Render(fmt.Sprintf("Files (%d)", len(p.files))))
+	if p.filesErr != nil {
+		lines = append(lines, errorStyle.Render(truncate(p.filesErr.Error(), width)))
+	}
+	if len(p.files) == 0 && p.filesErr == nil {
+		lines = append(lines, mutedStyle.Render("No other files."))
+	}
+	for _, file := range p.files {
+		size := formatSize(file.size)
+		name := file.path
+		if file.target != "" {
+			name += " -> " + file.target
+			size = "link"
+		}
+		name = truncate(name, width-len(size)-4)
+		padding := maxInt(1, width-len([]rune(name))-len(size)-2)
+		lines = append(lines, "  "+name+strings.Repeat(" ", padding)+mutedStyle.Render(size))
+	}
+
+	return lines
+}
+
+func (p *skillPreview) update(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "g", "home":
+		p.viewport.GotoTop()
+		return nil
+	case "G", "end":
+		p.viewport.GotoBottom()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	p.viewport, cmd = p.viewport.Update(msg)
+	return cmd
+}
+
+func listSkillFiles(root string) ([]previewFile, error) {
+	files := make([]previewFile, 0)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if rel == scan.MarkerFileName || rel == provenance.FileName {
+			return nil
+		}
+
+		file := previewFile{path: filepath.ToSlash(rel)}
+		if d.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			file.target = target
+		} else {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			file.size = info.Size()
+		}
+
+		files = append(files, file)
+		return nil
+	})
+	return files, err
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size) / unit
+	for _, suffix := range []string{"KB", "MB", "GB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+		value /= unit
+	}
+	return fmt.Sprintf("%.1f TB", value)
+}
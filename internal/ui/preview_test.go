@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"skiller/internal/provenance"
+	"skiller/internal/scan"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	src := strings.Join([]string{
+		"# Title",
+		"Use **bold** and `code`, see [docs](https://example.com).",
+		"",
+		"- first item that is long enough to wrap",
+		"  continued",
+		"1. numbered",
+		"",
+		"```sh",
+		"echo   keep spacing",
+		"```",
+		"> quoted",
+	}, "\n")
+
+	got := renderMarkdown(src, 30)
+	want := []string{
+		"Title",
+		"",
+		"Use bold and code, see docs",
+		"(https://example.com).",
+		"",
+		"• first item that is long",
+		"  enough to wrap continued",
+		"1. numbered",
+		"",
+		"  sh",
+		"  echo   keep spacing",
+		"",
+		"│ quoted",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected render:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestRenderMarkdownSplitsLongWords(t *testing.T) {
+	got := renderMarkdown(strings.Repeat("x", 25), 10)
+	want := []string{"xxxxxxxxxx", "xxxxxxxxxx", "xxxxx"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSkillPreviewListsFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "alpha")
+	files := map[string]string{
+		scan.MarkerFileName:     "---\ndescription: Alpha skill\n---\n# Alpha\nBody text.\n",
+		provenance.FileName:     "{}",
+		"scripts/run.sh":        "#!/bin/sh\n",
+		"references/guide.md":   strings.Repeat("a", 2048),
+		"references/.hidden.md": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	listed, err := listSkillFiles(dir)
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	want := []previewFile{
+		{path: "references/.hidden.md", size: 0},
+		{path: "references/guide.md", size: 2048},
+		{path: "scripts/run.sh", size: 10},
+	}
+	if !reflect.DeepEqual(listed, want) {
+		t.Fatalf("expected %+v, got %+v", want, listed)
+	}
+
+	preview := newSkillPreview(scan.Skill{Name: "alpha", Path: dir}, "registry")
+	preview.setSize(60, 10)
+	rendered := strings.Join(preview.render(60), "\n")
+	for _, expected := range []string{"Alpha", "Body text.", "Files (3)", "2.0 KB"} {
+		if !strings.Contains(rendered, expected) {
+			t.Fatalf("expected preview to contain %q:\n%s", expected, rendered)
+		}
+	}
+	if strings.Contains(rendered, "description:") {
+		t.Fatalf("expected frontmatter to be stripped from preview:\n%s", rendered)
+	}
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"skiller/internal/config"
+	"skiller/internal/scan"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type searchResult struct {
+	registry config.Registry
+	skill    scan.Skill
+}
+
+type globalSearch struct {
+	input    textinput.Model
+	results  []searchResult
+	selected int
+	offset   int
+}
+
+func (m *Model) beginSearch() {
+	m.errorMessage = ""
+	m.statusMessage = ""
+
+	input := textinput.New()
+	input.Prompt = ""
+	input.CharLimit = 256
+	input.Focus()
+
+	m.search = &globalSearch{input: input}
+	m.refreshSearch()
+}
+
+func (m *Model) refreshSearch() {
+	query := strings.TrimSpace(m.search.input.Value())
+
+	candidates := make([]searchResult, 0)
+	for _, registry := range m.registries {
+		for _, skill := range m.registrySkills[registry.ID] {
+			candidates = append(candidates, searchResult{registry: registry, skill: skill})
+		}
+	}
+
+	indexes := rankByScore(len(candidates), query, func(i int) (int, bool) {
+		return matchSkill(query, candidates[i].skill)
+	})
+
+	results := make([]searchResult, len(indexes))
+	for i, index := range indexes {
+		results[i] = candidates[index]
+	}
+
+	m.search.results = results
+	m.search.selected = 0
+	m.search.offset = 0
+}
+
+func (m *Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "esc":
+		m.search = nil
+		return m, nil
+	case "enter":
+		if len(m.search.results) == 0 {
+			return m, nil
+		}
+		m.jumpToSkill(m.search.results[m.search.selected])
+		m.search = nil
+		return m, nil
+	case "up", "ctrl+k", "ctrl+p":
+		m.search.selected = clamp(m.search.selected-1, 0, len(m.search.results)-1)
+		return m, nil
+	case "down", "ctrl+j", "ctrl+n":
+		m.search.selected = clamp(m.search.selected+1, 0, len(m.search.results)-1)
+		return m, nil
+	}
+
+	previous := m.search.input.Value()
+	var cmd tea.Cmd
+	m.search.input, cmd = m.search.input.Update(msg)
+	if m.search.input.Value() != previous {
+		m.refreshSearch()
+	}
+	return m, cmd
+}
+
+func (m *Model) jumpToSkill(result searchResult) {
+	m.filters[focusRegistries] = ""
+	m.filters[focusSkills] = ""
+
+	for i, registry := range m.registries {
+		if registry.ID == result.registry.ID {
+			m.selectedRegistry = i
+			break
+		}
+	}
+	for i, skill := range m.registrySkills[result.registry.ID] {
+		if skill.Path == result.skill.Path {
+			m.selectedSkill = i
+			break
+		}
+	}
+
+	m.focus = focusSkills
+	m.statusMessage = fmt.Sprintf("Jumped to %s in %s", result.skill.Name, result.registry.DisplayName())
+}
+
+func (m *Model) renderSearchPane(width, height int) string {
+	search := m.search
+	title := paneTitleStyle(true).Render(fmt.Sprintf("Search all registries (%d)", len(search.results)))
+	lines := []string{title, "/" + search.input.View()}
+
+	rows := maxInt(1, height-2)
+	if search.selected < search.offset {
+		search.offset = search.selected
+	}
+	if search.selected >= search.offset+rows {
+		search.offset = search.selected - rows + 1
+	}
+
+	if len(search.results) == 0 {
+		lines = append(lines, mutedStyle.Render("No matching skills."))
+	}
+	end := minInt(len(search.results), search.offset+rows)
+	for i := search.offset; i < end; i++ {
+		result := search.results[i]
+		skill := result.skill
+		skill.Name = fmt.Sprintf("%s  [%s]", skill.Name, result.registry.DisplayName())
+		lines = append(lines, renderSkillLine(skill, i == search.selected, false, "", width-2))
+	}
+
+	return paneBoxStyle(width, height, true).Render(strings.Join(lines, "\n"))
+}
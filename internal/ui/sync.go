@@ -0,0 +1,353 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"skiller/internal/config"
+	"skiller/internal/registrysync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	syncWorkers = 4
+
+	syncTimeout            = 2 * time.Minute
+	interactiveSyncTimeout = 4 * time.Minute
+
+	autoSyncEvery = time.Minute
+	autoSyncRetry = 5 * time.Minute
+)
+
+type autoSyncMsg time.Time
+
+func autoSyncTick() tea.Cmd {
+	return tea.Tick(autoSyncEvery, func(t time.Time) tea.Msg {
+		return autoSyncMsg(t)
+	})
+}
+
+type syncJob struct {
+	generation int
+	phase      string
+	started    time.Time
+	cancel     context.CancelFunc
+	manual     bool
+}
+
+type syncPhaseMsg struct {
+	registryID string
+	generation int
+	phase      registrysync.Phase
+}
+
+type syncDoneMsg struct {
+	registry   config.Registry
+	generation int
+	result     registrysync.SyncResult
+	err        error
+	elapsed    time.Duration
+}
+
+func (m *Model) startSync(registry config.Registry, manual bool) tea.Cmd {
+	if _, running := m.syncJobs[registry.ID]; running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.syncGeneration++
+	generation := m.syncGeneration
+	m.syncJobs[registry.ID] = &syncJob{
+		generation: generation,
+		phase:      "queued",
+		started:    time.Now(),
+		cancel:     cancel,
+		manual:     manual,
+	}
+
+	slots, events := m.syncSlots, m.syncEvents
+	return func() tea.Msg {
+		defer cancel()
+
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		case <-ctx.Done():
+			return syncDoneMsg{registry: registry, generation: generation, err: ctx.Err()}
+		}
+
+		ctx, stop := context.WithTimeout(ctx, syncTimeout)
+		defer stop()
+
+		started := time.Now()
+		result, err := registrysync.SyncRegistryContext(ctx, registry, registrysync.SyncOptions{
+			Progress: func(phase registrysync.Phase) {
+				select {
+				case events <- syncPhaseMsg{registryID: registry.ID, generation: generation, phase: phase}:
+				case <-ctx.Done():
+				}
+			},
+		})
+		return syncDoneMsg{registry: registry, generation: generation, result: result, err: err, elapsed: time.Since(started)}
+	}
+}
+
+func waitForSyncEvent(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+func (m *Model) syncing() bool {
+	return len(m.syncJobs) > 0
+}
+
+func (m *Model) withSpinner(wasSyncing bool, cmds ...tea.Cmd) tea.Cmd {
+	if !wasSyncing && m.syncing() {
+		cmds = append(cmds, m.spinner.Tick)
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *Model) handleSyncPhase(msg syncPhaseMsg) tea.Cmd {
+	job, ok := m.syncJobs[msg.registryID]
+	if ok && job.generation == msg.generation {
+		if job.phase == "queued" {
+			job.started = time.Now()
+		}
+		job.phase = string(msg.phase)
+	}
+	return waitForSyncEvent(m.syncEvents)
+}
+
+func (m *Model) handleSyncDone(msg syncDoneMsg) {
+	if msg.generation == 0 {
+		m.finishSync(msg, true)
+		return
+	}
+
+	// A cancelled job can still report after a newer sync of the same
+	// registry started; only the job's own generation may finish it.
+	job, ok := m.syncJobs[msg.registry.ID]
+	if !ok || job.generation != msg.generation {
+		return
+	}
+	delete(m.syncJobs, msg.registry.ID)
+	m.finishSync(msg, job.manual)
+}
+
+func (m *Model) finishSync(msg syncDoneMsg, manual bool) {
+	name := msg.registry.DisplayName()
+	id := msg.registry.ID
+
+	switch {
+	case errors.Is(msg.err, context.Canceled):
+		m.registrySyncStatus[id] = "canceled"
+		m.statusMessage = fmt.Sprintf("Cancelled sync of %s", name)
+	case errors.Is(msg.err, context.DeadlineExceeded):
+		m.registrySyncStatus[id] = "timed out"
+		if manual {
+			m.errorMessage = fmt.Sprintf("Sync of %s timed out", name)
+		}
+	case registrysync.IsAuthError(msg.err):
+		m.registrySyncStatus[id] = "auth required"
+		if manual {
+			m.errorMessage = fmt.Sprintf("Authentication required for %s. Press s again to sync with credential prompts.", name)
+		}
+	case msg.err != nil:
+		m.registrySyncStatus[id] = "error"
+		if manual {
+			m.errorMessage = fmt.Sprintf("%s: %v", name, msg.err)
+		}
+	default:
+		m.registrySyncStatus[id] = "done in " + formatElapsed(msg.elapsed)
+		if manual {
+			m.errorMessage = ""
+			m.statusMessage = fmt.Sprintf("Synced %s", name)
+			if summary := msg.result.Summary(); summary != "" {
+				m.statusMessage += ": " + summary
+			}
+		}
+	}
+
+	m.rescan()
+}
+
+func (m *Model) syncSelectedRegistry() tea.Cmd {
+	registry, ok := m.selectedRegistryValue()
+	if !ok {
+		m.statusMessage = "No registry selected"
+		return nil
+	}
+
+	if !registry.IsRemote() {
+		m.statusMessage = "Selected registry is local"
+		return nil
+	}
+
+	if registrysync.Offline() {
+		m.statusMessage = "Offline mode is on; registries are scanned from their caches"
+		return nil
+	}
+
+	if _, running := m.syncJobs[registry.ID]; running {
+		m.statusMessage = fmt.Sprintf("%s is already syncing", registry.DisplayName())
+		return nil
+	}
+
+	if m.registrySyncStatus[registry.ID] == "auth required" {
+		return m.syncInteractive(registry)
+	}
+
+	wasSyncing := m.syncing()
+	m.statusMessage = fmt.Sprintf("Syncing %s", registry.DisplayName())
+	return m.withSpinner(wasSyncing, m.startSync(registry, true))
+}
+
+func (m *Model) syncStaleRegistries(startup bool) tea.Cmd {
+	if registrysync.Offline() {
+		return nil
+	}
+
+	wasSyncing := m.syncing()
+	now := time.Now()
+
+	var cmds []tea.Cmd
+	for _, registry := range m.registries {
+		if !registry.IsRemote() {
+			continue
+		}
+		interval := registry.SyncEvery()
+		state := m.registryState[registry.ID]
+		if !state.Stale(interval, now) {
+			continue
+		}
+		if !startup && (interval == 0 || now.Sub(state.LastAttempt) < autoSyncRetry) {
+			continue
+		}
+		if cmd := m.startSync(registry, false); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	return m.withSpinner(wasSyncing, cmds...)
+}
+
+func (m *Model) syncAllRemoteRegistries(manual bool) tea.Cmd {
+	if registrysync.Offline() {
+		if manual {
+			m.statusMessage = "Offline mode is on; registries are scanned from their caches"
+		}
+		return nil
+	}
+
+	wasSyncing := m.syncing()
+
+	var cmds []tea.Cmd
+	for _, registry := range m.registries {
+		if !registry.IsRemote() {
+			continue
+		}
+		if cmd := m.startSync(registry, manual); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	if manual {
+		switch {
+		case len(cmds) > 0:
+			m.statusMessage = fmt.Sprintf("Syncing %d remote registries", len(cmds))
+		case m.syncing():
+			m.statusMessage = "Remote registries are already syncing"
+		default:
+			m.statusMessage = "No remote registries configured"
+		}
+	}
+
+	return m.withSpinner(wasSyncing, cmds...)
+}
+
+func (m *Model) cancelSelectedSync() {
+	registry, ok := m.selectedRegistryValue()
+	if !ok {
+		m.statusMessage = "No registry selected"
+		return
+	}
+
+	job, running := m.syncJobs[registry.ID]
+	if !running {
+		m.statusMessage = fmt.Sprintf("%s is not syncing", registry.DisplayName())
+		return
+	}
+
+	job.cancel()
+	job.phase = "cancelling"
+}
+
+func (m *Model) cancelAllSyncs() {
+	if !m.syncing() {
+		m.statusMessage = "No syncs in progress"
+		return
+	}
+
+	for _, job := range m.syncJobs {
+		job.cancel()
+		job.phase = "cancelling"
+	}
+}
+
+func (m *Model) syncInteractive(registry config.Registry) tea.Cmd {
+	command := &interactiveSync{registry: registry}
+	return tea.Exec(command, func(error) tea.Msg {
+		return syncDoneMsg{registry: registry, result: command.result, err: command.err, elapsed: command.elapsed}
+	})
+}
+
+type interactiveSync struct {
+	registry config.Registry
+	result   registrysync.SyncResult
+	err      error
+	elapsed  time.Duration
+}
+
+func (s *interactiveSync) Run() error {
+	started := time.Now()
+	s.result, s.err = registrysync.SyncRegistry(s.registry, true, interactiveSyncTimeout)
+	s.elapsed = time.Since(started)
+	return nil
+}
+
+func (s *interactiveSync) SetStdin(io.Reader)  {}
+func (s *interactiveSync) SetStdout(io.Writer) {}
+func (s *interactiveSync) SetStderr(io.Writer) {}
+
+func (m *Model) quit() tea.Cmd {
+	for _, job := range m.syncJobs {
+		job.cancel()
+	}
+	return tea.Quit
+}
+
+func (m *Model) syncStatusLabel(registry config.Registry) string {
+	job, running := m.syncJobs[registry.ID]
+	if !running {
+		return ""
+	}
+
+	label := m.spinner.View() + " " + job.phase
+	if job.phase != "queued" {
+		label += " " + formatElapsed(time.Since(job.started).Truncate(time.Second))
+	}
+	return label
+}
+
+func formatElapsed(elapsed time.Duration) string {
+	if elapsed < time.Second {
+		return elapsed.Round(10 * time.Millisecond).String()
+	}
+	return elapsed.Round(100 * time.Millisecond).String()
+}
@@ -0,0 +1,203 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"skiller/internal/config"
+	"skiller/internal/registrysync"
+	"skiller/internal/scan"
+	"skiller/internal/upgrade"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newTestModel(t *testing.T) *Model {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	return &Model{
+		cfg:                &config.Config{},
+		registrySkills:     map[string][]scan.Skill{},
+		registrySyncStatus: map[string]string{},
+		registryState:      map[string]registrysync.RegistryState{},
+		harnessSkills:      map[string][]scan.Skill{},
+		harnessStatus:      map[string]upgrade.Status{},
+		filters:            map[focusPane]string{},
+		markedSkills:       map[string]markedSkill{},
+		markedInstalls:     map[string]harnessRow{},
+		rangeAnchor:        map[focusPane]int{},
+		syncJobs:           map[string]*syncJob{},
+		syncSlots:          make(chan struct{}, syncWorkers),
+		syncEvents:         make(chan tea.Msg, syncWorkers),
+		spinner:            spinner.New(),
+	}
+}
+
+func TestCancelQueuedSync(t *testing.T) {
+	m := newTestModel(t)
+	registry := config.Registry{ID: "remote1", Type: config.RegistryTypeGit, Source: "https://example.com/skills.git"}
+	m.registries = []config.Registry{registry}
+
+	for i := 0; i < syncWorkers; i++ {
+		m.syncSlots <- struct{}{}
+	}
+
+	cmd := m.startSync(registry, true)
+	if cmd == nil || !m.syncing() {
+		t.Fatalf("expected sync job to be queued")
+	}
+	if again := m.startSync(registry, true); again != nil {
+		t.Fatalf("expected duplicate sync to be ignored")
+	}
+	if label := m.syncStatusLabel(registry); label == "" {
+		t.Fatalf("expected progress label for queued sync")
+	}
+
+	m.cancelSelectedSync()
+	msg, ok := cmd().(syncDoneMsg)
+	if !ok {
+		t.Fatalf("expected sync done message")
+	}
+
+	m.handleSyncDone(msg)
+	if m.syncing() {
+		t.Fatalf("expected job to be removed after cancellation")
+	}
+	if status := m.registrySyncStatus[registry.ID]; status != "canceled" {
+		t.Fatalf("expected canceled status, got %q", status)
+	}
+}
+
+func TestStaleSyncResultIsIgnored(t *testing.T) {
+	m := newTestModel(t)
+	registry := config.Registry{ID: "remote1", Type: config.RegistryTypeGit, Source: "https://example.com/skills.git"}
+	m.registries = []config.Registry{registry}
+
+	m.startSync(registry, false)
+	stale := syncDoneMsg{registry: registry, generation: m.syncGeneration - 1}
+	m.handleSyncDone(stale)
+
+	if !m.syncing() {
+		t.Fatalf("expected running job to survive a stale result")
+	}
+	m.cancelAllSyncs()
+}
+
+func TestOfflineShowsCacheAgeWithoutSyncing(t *testing.T) {
+	m := newTestModel(t)
+	registrysync.SetOffline(true)
+	t.Cleanup(func() { registrysync.SetOffline(false) })
+
+	registry := config.Registry{ID: "remote1", Type: config.RegistryTypeGit, Source: "https://example.com/skills.git"}
+	m.registries = []config.Registry{registry}
+	m.registrySyncStatus[registry.ID] = "cached"
+	m.registryState[registry.ID] = registrysync.RegistryState{SyncedAt: time.Now().Add(-74 * time.Hour)}
+
+	if cmd := m.syncAllRemoteRegistries(true); cmd != nil || m.syncing() {
+		t.Fatalf("expected no sync while offline")
+	}
+	if !strings.Contains(m.statusMessage, "Offline") {
+		t.Fatalf("expected an offline status, got %q", m.statusMessage)
+	}
+
+	if pane := m.renderRegistriesPane(80, 10); !strings.Contains(pane, "cached 3d ago") {
+		t.Fatalf("expected cache age in the registries pane, got:\n%s", pane)
+	}
+}
+
+func TestSyncStaleRegistriesHonoursIntervals(t *testing.T) {
+	m := newTestModel(t)
+	now := time.Now()
+
+	stale := config.Registry{ID: "stale", Type: config.RegistryTypeGit, Source: "https://example.com/a.git", SyncInterval: "1h"}
+	fresh := config.Registry{ID: "fresh", Type: config.RegistryTypeGit, Source: "https://example.com/b.git", SyncInterval: "1d"}
+	unscheduled := config.Registry{ID: "unscheduled", Type: config.RegistryTypeGit, Source: "https://example.com/c.git"}
+	m.registries = []config.Registry{stale, fresh, unscheduled}
+	for _, registry := range m.registries {
+		m.registryState[registry.ID] = registrysync.RegistryState{SyncedAt: now.Add(-2 * time.Hour), LastAttempt: now.Add(-2 * time.Hour)}
+	}
+
+	m.syncStaleRegistries(false)
+	if len(m.syncJobs) != 1 || m.syncJobs["stale"] == nil {
+		t.Fatalf("expected only the stale scheduled registry to sync, got %v", m.syncJobs)
+	}
+
+	m.syncStaleRegistries(true)
+	if len(m.syncJobs) != 2 || m.syncJobs["unscheduled"] == nil || m.syncJobs["fresh"] != nil {
+		t.Fatalf("expected startup to also sync registries without an interval, got %v", m.syncJobs)
+	}
+}
+
+func TestSkillsPaneHighlightsChangedSkills(t *testing.T) {
+	m := newTestModel(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	registry := config.Registry{ID: "remote1", Type: config.RegistryTypeGit, Source: "https://example.com/skills.git"}
+	root, err := config.RegistryScanRoot(registry)
+	if err != nil {
+		t.Fatalf("scan root failed: %v", err)
+	}
+	m.registries = []config.Registry{registry}
+	m.registrySkills[registry.ID] = []scan.Skill{
+		{Name: "alpha", Path: filepath.Join(root, "alpha")},
+		{Name: "beta", Path: filepath.Join(root, "tools", "beta")},
+		{Name: "gamma", Path: filepath.Join(root, "gamma")},
+	}
+	m.registryState[registry.ID] = registrysync.RegistryState{
+		Changes: registrysync.SkillChanges{Added: []string{"tools/beta"}, Changed: []string{"alpha"}},
+	}
+
+	pane := m.renderSkillsPane(80, 12)
+	for _, want := range []string{"alpha [changed]", "beta [new]"} {
+		if !strings.Contains(pane, want) {
+			t.Fatalf("expected %q in the skills pane, got:\n%s", want, pane)
+		}
+	}
+	if strings.Contains(pane, "gamma [") {
+		t.Fatalf("expected gamma without a badge, got:\n%s", pane)
+	}
+}
+
+func TestWhatsNewShowsLastChanges(t *testing.T) {
+	m := newTestModel(t)
+	m.width, m.height = 100, 30
+
+	registry := config.Registry{ID: "remote1", Type: config.RegistryTypeGit, Source: "https://example.com/skills.git"}
+	m.registries = []config.Registry{registry}
+	m.registryState[registry.ID] = registrysync.RegistryState{
+		Commit:         "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+		PreviousCommit: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		CommitsAhead:   2,
+		SyncedAt:       time.Now().Add(-2 * time.Hour),
+		Changes: registrysync.SkillChanges{
+			From:    "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			To:      "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+			Added:   []string{"tools/beta"},
+			Removed: []string{"gamma"},
+			Stat:    " tools/beta/SKILL.md | 1 +\n 1 file changed, 1 insertion(+)",
+		},
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if m.whatsNew == nil {
+		t.Fatalf("expected the what's new pane to open")
+	}
+	view := m.View()
+	for _, want := range []string{"What's new: skills", "Synced 2h ago", "Changes aaaaaaa..bbbbbbb", "Added (1)", "+ tools/beta", "Removed (1)", "- gamma", "1 file changed"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in the view, got:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Changed (") {
+		t.Fatalf("expected no changed section, got:\n%s", view)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.whatsNew != nil {
+		t.Fatalf("expected esc to close the what's new pane")
+	}
+}
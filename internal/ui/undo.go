@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"skiller/internal/trash"
+)
+
+const maxUndo = 20
+
+type undoAction struct {
+	entry   trash.Entry
+	replace bool
+}
+
+func (m *Model) pushUndo(entry trash.Entry, replace bool) {
+	if entry.ID == "" {
+		return
+	}
+	m.undo = append(m.undo, undoAction{entry: entry, replace: replace})
+	if len(m.undo) > maxUndo {
+		m.undo = m.undo[len(m.undo)-maxUndo:]
+	}
+}
+
+func (m *Model) pushUninstallUndo(entry trash.Entry) {
+	_, err := os.Lstat(entry.OriginalPath)
+	m.pushUndo(entry, err == nil)
+}
+
+func (m *Model) undoLast() {
+	if len(m.undo) == 0 {
+		m.statusMessage = "Nothing to undo"
+		return
+	}
+
+	action := m.undo[len(m.undo)-1]
+	m.undo = m.undo[:len(m.undo)-1]
+
+	entry, err := trash.Restore(action.entry.ID, action.replace)
+	if err != nil {
+		if errors.Is(err, trash.ErrDestinationExists) {
+			m.errorMessage = fmt.Sprintf("Cannot undo: %v", err)
+			return
+		}
+		m.errorMessage = err.Error()
+		return
+	}
+
+	m.errorMessage = ""
+	if action.replace {
+		m.statusMessage = fmt.Sprintf("Restored previous version of %s", entry.Name)
+	} else {
+		m.statusMessage = fmt.Sprintf("Restored %s to %s", entry.Name, entry.OriginalPath)
+	}
+	m.rescan()
+}
@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"skiller/internal/config"
+	"skiller/internal/registrysync"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type whatsNew struct {
+	registry config.Registry
+	state    registrysync.RegistryState
+
+	viewport viewport.Model
+	width    int
+}
+
+func newWhatsNew(registry config.Registry, state registrysync.RegistryState) *whatsNew {
+	return &whatsNew{registry: registry, state: state, viewport: viewport.New(0, 0)}
+}
+
+func (w *whatsNew) setSize(width, height int) {
+	w.viewport.Width = width
+	w.viewport.Height = height
+	if width == w.width {
+		return
+	}
+
+	w.width = width
+	w.viewport.SetContent(strings.Join(w.render(width, time.Now()), "\n"))
+}
+
+func (w *whatsNew) render(width int, now time.Time) []string {
+	state := w.state
+	changes := state.Changes
+
+	var lines []string
+	if state.SyncedAt.IsZero() {
+		lines = append(lines, mutedStyle.Render("Not synced yet."))
+	} else {
+		synced := fmt.Sprintf("Synced %s: %s", state.CachedAge(now), state.Summary())
+		lines = append(lines, mutedStyle.Render(truncate(synced, width)))
+	}
+	if changes.IsZero() {
+		return append(lines, "", mutedStyle.Render("No changes recorded. They appear after a sync that moves the registry."))
+	}
+
+	lines = append(lines, "", headingStyle.Render("Changes "+changes.Range()))
+	if !changes.HasSkills() {
+		lines = append(lines, mutedStyle.Render("No skill was added, changed or removed."))
+	}
+	for _, group := range []struct {
+		label  string
+		mark   string
+		skills []string
+	}{
+		{"Added", "+", changes.Added},
+		{"Changed", "~", changes.Changed},
+		{"Removed", "-", changes.Removed},
+	} {
+		if len(group.skills) == 0 {
+			continue
+		}
+		lines = append(lines, "", headingStyle.Render(fmt.Sprintf("%s (%d)", group.label, len(group.skills))))
+		for _, skill := range group.skills {
+			lines = append(lines, truncate("  "+group.mark+" "+skill, width))
+		}
+	}
+
+	if changes.Stat != "" {
+		lines = append(lines, "", mutedStyle.Render(strings.Repeat("─", width)))
+		for _, line := range strings.Split(changes.Stat, "\n") {
+			lines = append(lines, mutedStyle.Render(truncate(line, width)))
+		}
+	}
+	return lines
+}
+
+func (w *whatsNew) update(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+	w.viewport, cmd = w.viewport.Update(msg)
+	return cmd
+}
+
+func (m *Model) beginWhatsNew() {
+	m.errorMessage = ""
+	m.statusMessage = ""
+
+	registry, ok := m.selectedRegistryValue()
+	if !ok {
+		m.statusMessage = "No registry selected"
+		return
+	}
+	if !registry.IsRemote() {
+		m.statusMessage = "Local registries have no sync history"
+		return
+	}
+
+	m.whatsNew = newWhatsNew(registry, m.registryState[registry.ID])
+	m.resizePreview()
+}
+
+func (m *Model) updateWhatsNew(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, m.quit()
+	case "w", "q", "esc", "enter":
+		m.whatsNew = nil
+		return m, nil
+	}
+
+	return m, m.whatsNew.update(msg)
+}
+
+func (m *Model) renderWhatsNewPane(width, height int) string {
+	title := paneTitleStyle(true).Render(truncate("What's new: "+m.whatsNew.registry.DisplayName(), width-4))
+	lines := []string{title, m.whatsNew.viewport.View()}
+	return paneBoxStyle(width, height, true).Render(strings.Join(lines, "\n"))
+}
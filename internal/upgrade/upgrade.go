@@ -0,0 +1,194 @@
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"skiller/internal/config"
+	"skiller/internal/install"
+	"skiller/internal/provenance"
+	"skiller/internal/registrysync"
+	"skiller/internal/scan"
+)
+
+type State string
+
+const (
+	StateUpToDate  State = "up-to-date"
+	StateOutdated  State = "outdated"
+	StateModified  State = "locally modified"
+	StateOrphaned  State = "orphaned"
+	StateUnmanaged State = "unmanaged"
+	StateLinked    State = "linked"
+	StateAdapted   State = "adapted"
+)
+
+var (
+	ErrLocallyModified = errors.New("skill has local modifications")
+	ErrNotUpgradable   = errors.New("skill cannot be upgraded")
+)
+
+type Status struct {
+	State     State
+	Harness   string
+	Installed scan.Skill
+	Record    provenance.Record
+	Registry  config.Registry
+	Source    scan.Skill
+}
+
+type Checker struct {
+	registries   map[string]config.Registry
+	skills       map[string][]scan.Skill
+	sourceHashes map[string]string
+}
+
+func NewChecker(registries []config.Registry, registrySkills map[string][]scan.Skill) *Checker {
+	byID := make(map[string]config.Registry, len(registries))
+	for _, registry := range registries {
+		byID[registry.ID] = registry
+	}
+
+	return &Checker{
+		registries:   byID,
+		skills:       registrySkills,
+		sourceHashes: map[string]string{},
+	}
+}
+
+func (c *Checker) Check(harness string, installed scan.Skill) (Status, error) {
+	status := Status{Harness: harness, Installed: installed}
+	if installed.Link != "" {
+		status.State = StateUnmanaged
+		if config.LinkManaged(c.registryList(), installed.Link) {
+			status.State = StateLinked
+		}
+		return status, nil
+	}
+	if installed.Adapted {
+		status.State = StateAdapted
+		return status, nil
+	}
+
+	record, ok, err := provenance.Read(installed.Path)
+	if err != nil {
+		return status, err
+	}
+	if !ok {
+		status.State = StateUnmanaged
+		return status, nil
+	}
+	status.Record = record
+
+	installedHash, err := provenance.HashSkill(installed.Path)
+	if err != nil {
+		return status, err
+	}
+
+	registry, source, found := c.findSource(record)
+	status.Registry = registry
+	status.Source = source
+
+	if installedHash != record.ContentHash && record.InstallMode == string(config.InstallModeHardlink) && found {
+		sourceHash, err := c.sourceHash(source.Path)
+		if err != nil {
+			return status, err
+		}
+		if sourceHash == installedHash {
+			status.State = StateUpToDate
+			return status, nil
+		}
+	}
+
+	switch {
+	case installedHash != record.ContentHash:
+		status.State = StateModified
+	case !found:
+		status.State = StateOrphaned
+	default:
+		sourceHash, err := c.sourceHash(source.Path)
+		if err != nil {
+			return status, err
+		}
+		if sourceHash == record.ContentHash {
+			status.State = StateUpToDate
+		} else {
+			status.State = StateOutdated
+		}
+	}
+
+	return status, nil
+}
+
+func (c *Checker) registryList() []config.Registry {
+	registries := make([]config.Registry, 0, len(c.registries))
+	for _, registry := range c.registries {
+		registries = append(registries, registry)
+	}
+	return registries
+}
+
+func (c *Checker) findSource(record provenance.Record) (config.Registry, scan.Skill, bool) {
+	registry, ok := c.registries[record.RegistryID]
+	if !ok {
+		return config.Registry{}, scan.Skill{}, false
+	}
+
+	root, err := config.RegistryScanRoot(registry)
+	if err != nil {
+		return registry, scan.Skill{}, false
+	}
+
+	sourcePath := filepath.Join(root, filepath.FromSlash(record.SourcePath))
+	for _, skill := range c.skills[registry.ID] {
+		if skill.Path == sourcePath {
+			return registry, skill, true
+		}
+	}
+	return registry, scan.Skill{}, false
+}
+
+func (c *Checker) sourceHash(path string) (string, error) {
+	if hash, ok := c.sourceHashes[path]; ok {
+		return hash, nil
+	}
+
+	hash, err := provenance.HashSkill(path)
+	if err != nil {
+		return "", err
+	}
+	c.sourceHashes[path] = hash
+	return hash, nil
+}
+
+func Upgrade(status Status, force bool) (install.InstallResult, error) {
+	switch status.State {
+	case StateOutdated:
+	case StateModified:
+		if !force {
+			return install.InstallResult{}, fmt.Errorf("%s: %w", status.Installed.Name, ErrLocallyModified)
+		}
+		if status.Source.Path == "" {
+			return install.InstallResult{}, fmt.Errorf("%s: source no longer exists in registry: %w", status.Installed.Name, ErrNotUpgradable)
+		}
+	case StateUpToDate:
+		return install.InstallResult{Name: status.Installed.Name, Destination: status.Installed.Path}, nil
+	default:
+		return install.InstallResult{}, fmt.Errorf("%s is %s: %w", status.Installed.Name, status.State, ErrNotUpgradable)
+	}
+
+	if err := registrysync.FetchSkill(status.Registry, status.Source.Path); err != nil {
+		return install.InstallResult{}, err
+	}
+	record, err := provenance.ForSkill(status.Registry, status.Source)
+	if err != nil {
+		return install.InstallResult{}, err
+	}
+
+	return install.InstallSkillWithOptions(status.Source.Path, status.Harness, install.ConflictOverwrite, install.Options{
+		Name:       status.Installed.Name,
+		Mode:       config.InstallMode(status.Record.InstallMode),
+		Provenance: &record,
+	})
+}
@@ -0,0 +1,202 @@
+package upgrade
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"skiller/internal/config"
+	"skiller/internal/install"
+	"skiller/internal/provenance"
+	"skiller/internal/scan"
+)
+
+type fixture struct {
+	registry config.Registry
+	harness  string
+	source   string
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	root := t.TempDir()
+	registryRoot := filepath.Join(root, "registry")
+	source := filepath.Join(registryRoot, "alpha")
+	writeSkill(t, source, "# alpha v1")
+
+	registry := config.Registry{ID: "reg1", Type: config.RegistryTypeLocal, Source: registryRoot}
+	harness := filepath.Join(root, "harness")
+
+	record, err := provenance.ForSkill(registry, scan.Skill{Name: "alpha", Path: source})
+	if err != nil {
+		t.Fatalf("provenance failed: %v", err)
+	}
+	if _, err := install.InstallSkillWithOptions(source, harness, install.ConflictSkip, install.Options{Provenance: &record}); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	return fixture{registry: registry, harness: harness, source: source}
+}
+
+func (f fixture) check(t *testing.T) Status {
+	t.Helper()
+
+	skills, err := scan.ScanRegistry(f.registry.Source)
+	if err != nil {
+		t.Fatalf("scan registry failed: %v", err)
+	}
+	installed, err := scan.ScanHarness(f.harness)
+	if err != nil || len(installed) != 1 {
+		t.Fatalf("scan harness failed: %v (%d skills)", err, len(installed))
+	}
+
+	checker := NewChecker([]config.Registry{f.registry}, map[string][]scan.Skill{f.registry.ID: skills})
+	status, err := checker.Check(f.harness, installed[0])
+	if err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	return status
+}
+
+func writeSkill(t *testing.T, dir, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write marker failed: %v", err)
+	}
+}
+
+func TestCheckUpToDateAndOutdated(t *testing.T) {
+	f := newFixture(t)
+
+	if status := f.check(t); status.State != StateUpToDate {
+		t.Fatalf("expected up-to-date, got %s", status.State)
+	}
+
+	writeSkill(t, f.source, "# alpha v2")
+	status := f.check(t)
+	if status.State != StateOutdated {
+		t.Fatalf("expected outdated, got %s", status.State)
+	}
+
+	if _, err := Upgrade(status, false); err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(f.harness, "alpha", "SKILL.md"))
+	if err != nil || string(content) != "# alpha v2" {
+		t.Fatalf("expected upgraded content, got %q (%v)", content, err)
+	}
+	if status := f.check(t); status.State != StateUpToDate {
+		t.Fatalf("expected up-to-date after upgrade, got %s", status.State)
+	}
+}
+
+func TestUpgradeRefusesLocalModificationsUnlessForced(t *testing.T) {
+	f := newFixture(t)
+
+	writeSkill(t, f.source, "# alpha v2")
+	writeSkill(t, filepath.Join(f.harness, "alpha"), "# alpha edited")
+
+	status := f.check(t)
+	if status.State != StateModified {
+		t.Fatalf("expected locally modified, got %s", status.State)
+	}
+
+	if _, err := Upgrade(status, false); !errors.Is(err, ErrLocallyModified) {
+		t.Fatalf("expected local modification error, got %v", err)
+	}
+
+	if _, err := Upgrade(status, true); err != nil {
+		t.Fatalf("forced upgrade failed: %v", err)
+	}
+	if status := f.check(t); status.State != StateUpToDate {
+		t.Fatalf("expected up-to-date after forced upgrade, got %s", status.State)
+	}
+}
+
+func TestCheckOrphanedAndUnmanaged(t *testing.T) {
+	f := newFixture(t)
+
+	if err := os.RemoveAll(f.source); err != nil {
+		t.Fatalf("remove source failed: %v", err)
+	}
+	if status := f.check(t); status.State != StateOrphaned {
+		t.Fatalf("expected orphaned, got %s", status.State)
+	}
+
+	if err := os.Remove(filepath.Join(f.harness, "alpha", provenance.FileName)); err != nil {
+		t.Fatalf("remove sidecar failed: %v", err)
+	}
+	if status := f.check(t); status.State != StateUnmanaged {
+		t.Fatalf("expected unmanaged, got %s", status.State)
+	}
+}
+
+func TestCheckLinkedInstalls(t *testing.T) {
+	root := t.TempDir()
+	registryRoot := filepath.Join(root, "registry")
+	source := filepath.Join(registryRoot, "alpha")
+	writeSkill(t, source, "# alpha v1")
+
+	registry := config.Registry{ID: "reg1", Type: config.RegistryTypeLocal, Source: registryRoot}
+	record, err := provenance.ForSkill(registry, scan.Skill{Name: "alpha", Path: source})
+	if err != nil {
+		t.Fatalf("provenance failed: %v", err)
+	}
+
+	modes := map[config.InstallMode]State{
+		config.InstallModeSymlink:  StateLinked,
+		config.InstallModeHardlink: StateUpToDate,
+	}
+	for mode, want := range modes {
+		f := fixture{registry: registry, harness: filepath.Join(root, string(mode)), source: source}
+		if _, err := install.InstallSkillWithOptions(source, f.harness, install.ConflictSkip, install.Options{Mode: mode, Provenance: &record}); err != nil {
+			t.Fatalf("%s install failed: %v", mode, err)
+		}
+		if status := f.check(t); status.State != want {
+			t.Fatalf("%s: expected %s after install, got %s", mode, want, status.State)
+		}
+	}
+
+	file, err := os.OpenFile(filepath.Join(source, "SKILL.md"), os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if _, err := file.WriteString("# alpha v2"); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	file.Close()
+
+	for mode, want := range modes {
+		f := fixture{registry: registry, harness: filepath.Join(root, string(mode)), source: source}
+		if status := f.check(t); status.State != want {
+			t.Fatalf("%s: expected %s, got %s", mode, want, status.State)
+		}
+	}
+}
+
+func TestCheckTreatsForeignLinksAsUnmanaged(t *testing.T) {
+	root := t.TempDir()
+	registryRoot := filepath.Join(root, "registry")
+	writeSkill(t, filepath.Join(registryRoot, "alpha"), "# alpha")
+	own := filepath.Join(root, "mine", "alpha")
+	writeSkill(t, own, "# my alpha")
+
+	f := fixture{registry: config.Registry{ID: "reg1", Type: config.RegistryTypeLocal, Source: registryRoot}, harness: filepath.Join(root, "harness")}
+	if err := os.MkdirAll(f.harness, 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.Symlink(own, filepath.Join(f.harness, "alpha")); err != nil {
+		t.Fatalf("symlink failed: %v", err)
+	}
+	if status := f.check(t); status.State != StateUnmanaged {
+		t.Fatalf("expected a hand-made link to be unmanaged, got %s", status.State)
+	}
+}